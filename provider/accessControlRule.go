@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type AccessControlRule struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type AccessControlRuleArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the rule is created in.
+	OrganizationId string `pulumi:"organizationId"`
+	// The rule name. Rule names are unique within an organization.
+	Name string `pulumi:"name"`
+	// The rule description.
+	Description string `pulumi:"description"`
+	// The effect of the rule when it matches.
+	Effect AccessControlEffect `pulumi:"effect"`
+	// Access protocol actions to include in the rule. Valid values include ActiveSync,
+	// AutoDiscover, EWS, IMAP, SMTP, WindowsOutlook, and WebMail.
+	Actions []string `pulumi:"actions,optional"`
+	// Access protocol actions to exclude from the rule.
+	NotActions []string `pulumi:"notActions,optional"`
+	// IPv4 CIDR ranges to include in the rule.
+	IpRanges []string `pulumi:"ipRanges,optional"`
+	// IPv4 CIDR ranges to exclude from the rule.
+	NotIpRanges []string `pulumi:"notIpRanges,optional"`
+	// User IDs to include in the rule.
+	UserIds []string `pulumi:"userIds,optional"`
+	// User IDs to exclude from the rule.
+	NotUserIds []string `pulumi:"notUserIds,optional"`
+	// Impersonation role IDs to include in the rule.
+	ImpersonationRoleIds []string `pulumi:"impersonationRoleIds,optional"`
+	// Impersonation role IDs to exclude from the rule.
+	NotImpersonationRoleIds []string `pulumi:"notImpersonationRoleIds,optional"`
+}
+
+type AccessControlEffect string
+
+const (
+	AccessControlEffectAllow AccessControlEffect = "ALLOW"
+	AccessControlEffectDeny  AccessControlEffect = "DENY"
+)
+
+func (AccessControlEffect) Values() []infer.EnumValue[AccessControlEffect] {
+	return []infer.EnumValue[AccessControlEffect]{
+		{Name: "Allow", Value: AccessControlEffectAllow, Description: "Allow access for matching requests."},
+		{Name: "Deny", Value: AccessControlEffectDeny, Description: "Deny access for matching requests."},
+	}
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type AccessControlRuleState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	AccessControlRuleArgs
+}
+
+// Check validates the inputs before they are used.
+func (AccessControlRule) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (AccessControlRuleArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args AccessControlRuleArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.checkAll("ipRanges", func() error { return validateCidrRanges(args.IpRanges) })
+		c.checkAll("notIpRanges", func() error { return validateCidrRanges(args.NotIpRanges) })
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (AccessControlRule) Create(ctx p.Context, name string, input AccessControlRuleArgs, preview bool) (string, AccessControlRuleState, error) {
+	state := AccessControlRuleState{AccessControlRuleArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	err := putAccessControlRule(ctx, input)
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.Name, state, nil
+}
+
+func (AccessControlRule) Diff(ctx p.Context, id string, olds AccessControlRuleState, news AccessControlRuleArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Name
+	if olds.Name != news.Name {
+		diffs["name"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Description
+	if olds.Description != news.Description {
+		diffs["description"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Effect
+	if olds.Effect != news.Effect {
+		diffs["effect"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Matchers
+	for key, values := range map[string][2][]string{
+		"actions":                 {olds.Actions, news.Actions},
+		"notActions":              {olds.NotActions, news.NotActions},
+		"ipRanges":                {olds.IpRanges, news.IpRanges},
+		"notIpRanges":             {olds.NotIpRanges, news.NotIpRanges},
+		"userIds":                 {olds.UserIds, news.UserIds},
+		"notUserIds":              {olds.NotUserIds, news.NotUserIds},
+		"impersonationRoleIds":    {olds.ImpersonationRoleIds, news.ImpersonationRoleIds},
+		"notImpersonationRoleIds": {olds.NotImpersonationRoleIds, news.NotImpersonationRoleIds},
+	} {
+		if sliceDiff(values[0], values[1]) {
+			diffs[key] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+		}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+func (AccessControlRule) Update(ctx p.Context, id string, olds AccessControlRuleState, news AccessControlRuleArgs, preview bool) (AccessControlRuleState, error) {
+	state := AccessControlRuleState{AccessControlRuleArgs: news}
+	if preview {
+		return state, nil
+	}
+
+	// PutAccessControlRule overwrites the rule with the same name.
+	err := putAccessControlRule(ctx, news)
+
+	return state, err
+}
+
+func validateCidrRanges(ipRanges []string) error {
+	for _, ipRange := range ipRanges {
+		ip, _, err := net.ParseCIDR(ipRange)
+		if err != nil || ip.To4() == nil {
+			return fmt.Errorf("%q is not a valid IPv4 CIDR range", ipRange)
+		}
+	}
+	return nil
+}
+
+func putAccessControlRule(ctx p.Context, input AccessControlRuleArgs) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return err
+	}
+
+	// Referenced users must exist, otherwise the rule silently never matches them.
+	err = ensureUsersExist(ctx, workmailclient, input.OrganizationId, append(append([]string{}, input.UserIds...), input.NotUserIds...))
+	if err != nil {
+		return fmt.Errorf("access control rule %s: %w", input.Name, err)
+	}
+
+	_, err = workmailclient.PutAccessControlRule(ctx, &workmail.PutAccessControlRuleInput{
+		OrganizationId:          &input.OrganizationId,
+		Name:                    &input.Name,
+		Description:             &input.Description,
+		Effect:                  types.AccessControlRuleEffect(input.Effect),
+		Actions:                 input.Actions,
+		NotActions:              input.NotActions,
+		IpRanges:                input.IpRanges,
+		NotIpRanges:             input.NotIpRanges,
+		UserIds:                 input.UserIds,
+		NotUserIds:              input.NotUserIds,
+		ImpersonationRoleIds:    input.ImpersonationRoleIds,
+		NotImpersonationRoleIds: input.NotImpersonationRoleIds,
+	})
+
+	return err
+}
+
+func (AccessControlRule) Read(ctx p.Context, id string, inputs AccessControlRuleArgs, state AccessControlRuleState) (string, AccessControlRuleArgs, AccessControlRuleState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	rules, err := workmailclient.ListAccessControlRules(ctx, &workmail.ListAccessControlRulesInput{
+		OrganizationId: &state.OrganizationId,
+	})
+	if err != nil {
+		return "", inputs, state, err
+	}
+	rule, found := Find(func(rule types.AccessControlRule) bool {
+		return rule.Name != nil && *rule.Name == id
+	})(rules.Rules)
+	if !found {
+		// The rule has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+
+	state.Name = id
+	state.Description = ifNotNil(rule.Description, "")
+	state.Effect = AccessControlEffect(rule.Effect)
+	state.Actions = rule.Actions
+	state.NotActions = rule.NotActions
+	state.IpRanges = rule.IpRanges
+	state.NotIpRanges = rule.NotIpRanges
+	state.UserIds = rule.UserIds
+	state.NotUserIds = rule.NotUserIds
+	state.ImpersonationRoleIds = rule.ImpersonationRoleIds
+	state.NotImpersonationRoleIds = rule.NotImpersonationRoleIds
+
+	return id, state.AccessControlRuleArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (AccessControlRule) Delete(ctx p.Context, id string, props AccessControlRuleState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteAccessControlRule(ctx, &workmail.DeleteAccessControlRuleInput{
+		OrganizationId: &props.OrganizationId,
+		Name:           &id,
+	})
+
+	return err
+}
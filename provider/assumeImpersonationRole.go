@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type AssumeImpersonationRole struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type AssumeImpersonationRoleArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The impersonation role id.
+	ImpersonationRoleId string `pulumi:"impersonationRoleId"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type AssumeImpersonationRoleResult struct {
+	// The impersonation token, used for EWS requests on behalf of the target users.
+	Token string `pulumi:"token" provider:"secret"`
+	// The number of seconds the token is valid for.
+	ExpiresIn int `pulumi:"expiresIn"`
+}
+
+// Call obtains an impersonation token for testing an impersonation role.
+func (AssumeImpersonationRole) Call(ctx p.Context, input AssumeImpersonationRoleArgs) (AssumeImpersonationRoleResult, error) {
+	result := AssumeImpersonationRoleResult{}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	role, err := workmailclient.AssumeImpersonationRole(ctx, &workmail.AssumeImpersonationRoleInput{
+		OrganizationId:      &input.OrganizationId,
+		ImpersonationRoleId: &input.ImpersonationRoleId,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Token = ifNotNil(role.Token, "")
+	result.ExpiresIn = int(ifNotNil(role.ExpiresIn, 0))
+
+	return result, nil
+}
@@ -0,0 +1,396 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type AuditLogConfiguration struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+//
+// WorkMail audit logs are delivered through CloudWatch Logs log deliveries. Each log
+// type is enabled independently by setting its destination, which can be the ARN of a
+// CloudWatch Logs log group, an S3 bucket or a Firehose delivery stream.
+type AuditLogConfigurationArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization whose audit logs are delivered.
+	OrganizationId string `pulumi:"organizationId"`
+	// The destination ARN for access control logs.
+	AccessControlLogsDestinationArn *string `pulumi:"accessControlLogsDestinationArn,optional"`
+	// The destination ARN for authentication logs.
+	AuthenticationLogsDestinationArn *string `pulumi:"authenticationLogsDestinationArn,optional"`
+	// The destination ARN for availability provider logs.
+	AvailabilityProviderLogsDestinationArn *string `pulumi:"availabilityProviderLogsDestinationArn,optional"`
+	// The destination ARN for mailbox access logs.
+	MailboxAccessLogsDestinationArn *string `pulumi:"mailboxAccessLogsDestinationArn,optional"`
+	// The destination ARN for personal access token logs.
+	PersonalAccessTokenLogsDestinationArn *string `pulumi:"personalAccessTokenLogsDestinationArn,optional"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type AuditLogConfigurationState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	AuditLogConfigurationArgs
+
+	// The log deliveries of the enabled log types.
+	Deliveries []AuditLogDelivery `pulumi:"deliveries"`
+}
+
+type AuditLogDelivery struct {
+	// The WorkMail log type.
+	LogType string `pulumi:"logType"`
+	// The id of the CloudWatch Logs delivery.
+	DeliveryId string `pulumi:"deliveryId"`
+	// The ARN the logs are delivered to.
+	DestinationArn string `pulumi:"destinationArn"`
+}
+
+// auditLog ties a WorkMail log type to the argument holding its destination.
+type auditLog struct {
+	logType     string
+	name        string
+	property    string
+	destination **string
+}
+
+func auditLogs(args *AuditLogConfigurationArgs) []auditLog {
+	return []auditLog{
+		{"ACCESS_CONTROL_LOGS", "access-control", "accessControlLogsDestinationArn", &args.AccessControlLogsDestinationArn},
+		{"AUTHENTICATION_LOGS", "authentication", "authenticationLogsDestinationArn", &args.AuthenticationLogsDestinationArn},
+		{"WORKMAIL_AVAILABILITY_PROVIDER_LOGS", "availability-provider", "availabilityProviderLogsDestinationArn", &args.AvailabilityProviderLogsDestinationArn},
+		{"WORKMAIL_MAILBOX_ACCESS_LOGS", "mailbox-access", "mailboxAccessLogsDestinationArn", &args.MailboxAccessLogsDestinationArn},
+		{"WORKMAIL_PERSONAL_ACCESS_TOKEN_LOGS", "personal-access-token", "personalAccessTokenLogsDestinationArn", &args.PersonalAccessTokenLogsDestinationArn},
+	}
+}
+
+// Check validates the inputs before they are used.
+func (AuditLogConfiguration) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (AuditLogConfigurationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args AuditLogConfigurationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		for _, log := range auditLogs(&args) {
+			c.checkAll(log.property, func() error { return validateAuditLogDestination(log) })
+		}
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (AuditLogConfiguration) Create(ctx p.Context, name string, input AuditLogConfigurationArgs, preview bool) (string, AuditLogConfigurationState, error) {
+	state := AuditLogConfigurationState{AuditLogConfigurationArgs: input, Deliveries: []AuditLogDelivery{}}
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail and CloudWatch Logs service clients for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+	logsclient, err := logsClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	organization, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
+		OrganizationId: &input.OrganizationId,
+	})
+	if err != nil {
+		return "", state, err
+	}
+
+	for _, log := range auditLogs(&input) {
+		if *log.destination == nil {
+			continue
+		}
+		delivery, err := enableAuditLog(ctx, logsclient, *organization.ARN, input.OrganizationId, log)
+		if err != nil {
+			// Keep the deliveries that were enabled, so they are not orphaned.
+			return input.OrganizationId, withEnabledDestinations(state), infer.ResourceInitFailedError{Reasons: []string{err.Error()}}
+		}
+		state.Deliveries = append(state.Deliveries, delivery)
+	}
+
+	return input.OrganizationId, state, nil
+}
+
+func (AuditLogConfiguration) Diff(ctx p.Context, id string, olds AuditLogConfigurationState, news AuditLogConfigurationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Destinations
+	oldLogs := auditLogs(&olds.AuditLogConfigurationArgs)
+	for i, log := range auditLogs(&news) {
+		if ptrDiff(*oldLogs[i].destination, *log.destination) {
+			diffs[log.property] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+		}
+	}
+
+	// Delivery sources are named after the organization, so the old ones have to go first.
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+func (AuditLogConfiguration) Update(ctx p.Context, id string, olds AuditLogConfigurationState, news AuditLogConfigurationArgs, preview bool) (AuditLogConfigurationState, error) {
+	state := AuditLogConfigurationState{AuditLogConfigurationArgs: news, Deliveries: []AuditLogDelivery{}}
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail and CloudWatch Logs service clients for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+	logsclient, err := logsClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	organization, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
+		OrganizationId: &news.OrganizationId,
+	})
+	if err != nil {
+		return state, err
+	}
+
+	logs := auditLogs(&news)
+	failed := func(i int, err error) (AuditLogConfigurationState, error) {
+		// The logs after the failed one still have their old deliveries.
+		for _, log := range logs[i+1:] {
+			delivery, found := findAuditLogDelivery(olds.Deliveries, log.logType)
+			if found {
+				state.Deliveries = append(state.Deliveries, delivery)
+			}
+		}
+		return withEnabledDestinations(state), infer.ResourceInitFailedError{Reasons: []string{err.Error()}}
+	}
+
+	oldLogs := auditLogs(&olds.AuditLogConfigurationArgs)
+	for i, log := range logs {
+		delivery, found := findAuditLogDelivery(olds.Deliveries, log.logType)
+
+		if !ptrDiff(*oldLogs[i].destination, *log.destination) {
+			if found {
+				state.Deliveries = append(state.Deliveries, delivery)
+			}
+			continue
+		}
+
+		// The destination of a delivery cannot be changed, so it is recreated.
+		if found {
+			err = disableAuditLog(ctx, logsclient, news.OrganizationId, log, delivery.DeliveryId)
+			if err != nil {
+				state.Deliveries = append(state.Deliveries, delivery)
+				return failed(i, err)
+			}
+		}
+		if *log.destination != nil {
+			delivery, err = enableAuditLog(ctx, logsclient, *organization.ARN, news.OrganizationId, log)
+			if err != nil {
+				return failed(i, err)
+			}
+			state.Deliveries = append(state.Deliveries, delivery)
+		}
+	}
+
+	return state, nil
+}
+
+func findAuditLogDelivery(deliveries []AuditLogDelivery, logType string) (AuditLogDelivery, bool) {
+	return Find(func(delivery AuditLogDelivery) bool {
+		return delivery.LogType == logType
+	})(deliveries)
+}
+
+// withEnabledDestinations limits the destinations of a partially applied configuration to
+// the log types that have a delivery, so the next update enables the others.
+func withEnabledDestinations(state AuditLogConfigurationState) AuditLogConfigurationState {
+	for _, log := range auditLogs(&state.AuditLogConfigurationArgs) {
+		*log.destination = nil
+		if delivery, found := findAuditLogDelivery(state.Deliveries, log.logType); found {
+			*log.destination = &delivery.DestinationArn
+		}
+	}
+	return state
+}
+
+// validateAuditLogDestination ensures the destination is the ARN of a CloudWatch Logs log
+// group, an S3 bucket or a Firehose delivery stream.
+func validateAuditLogDestination(log auditLog) error {
+	if *log.destination == nil {
+		return nil
+	}
+	arn := **log.destination
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return fmt.Errorf("%s destination %q is not a valid ARN", log.logType, arn)
+	}
+	switch service, resource := parts[2], parts[5]; {
+	case service == "logs" && strings.HasPrefix(resource, "log-group:"):
+	case service == "s3" && resource != "" && !strings.Contains(resource, "/"):
+	case service == "firehose" && strings.HasPrefix(resource, "deliverystream/"):
+	default:
+		return fmt.Errorf("%s destination %q must be a CloudWatch Logs log group, S3 bucket or Firehose delivery stream ARN", log.logType, arn)
+	}
+	return nil
+}
+
+// enableAuditLog creates the delivery source, destination and delivery for a log type.
+func enableAuditLog(ctx p.Context, logsclient *cloudwatchlogs.Client, organizationArn string, organizationId string, log auditLog) (AuditLogDelivery, error) {
+	name := organizationId + "-" + log.name
+
+	_, err := logsclient.PutDeliverySource(ctx, &cloudwatchlogs.PutDeliverySourceInput{
+		Name:        &name,
+		ResourceArn: &organizationArn,
+		LogType:     &log.logType,
+	})
+	if err != nil {
+		return AuditLogDelivery{}, err
+	}
+
+	destination, err := logsclient.PutDeliveryDestination(ctx, &cloudwatchlogs.PutDeliveryDestinationInput{
+		Name: &name,
+		DeliveryDestinationConfiguration: &logstypes.DeliveryDestinationConfiguration{
+			DestinationResourceArn: *log.destination,
+		},
+	})
+	if err != nil {
+		// Roll back the delivery source, so it is not orphaned.
+		return AuditLogDelivery{}, errors.Join(err, deleteAuditLogEndpoints(ctx, logsclient, name))
+	}
+
+	delivery, err := logsclient.CreateDelivery(ctx, &cloudwatchlogs.CreateDeliveryInput{
+		DeliverySourceName:     &name,
+		DeliveryDestinationArn: destination.DeliveryDestination.Arn,
+	})
+	if err != nil {
+		// Roll back the delivery source and destination, so they are not orphaned.
+		return AuditLogDelivery{}, errors.Join(err, deleteAuditLogEndpoints(ctx, logsclient, name))
+	}
+
+	return AuditLogDelivery{
+		LogType:        log.logType,
+		DeliveryId:     *delivery.Delivery.Id,
+		DestinationArn: **log.destination,
+	}, nil
+}
+
+// disableAuditLog removes the delivery, destination and delivery source of a log type.
+// Parts that no longer exist are skipped.
+func disableAuditLog(ctx p.Context, logsclient *cloudwatchlogs.Client, organizationId string, log auditLog, deliveryId string) error {
+	name := organizationId + "-" + log.name
+	var notFound *logstypes.ResourceNotFoundException
+
+	_, err := logsclient.DeleteDelivery(ctx, &cloudwatchlogs.DeleteDeliveryInput{Id: &deliveryId})
+	if err != nil && !errors.As(err, &notFound) {
+		return err
+	}
+	return deleteAuditLogEndpoints(ctx, logsclient, name)
+}
+
+// deleteAuditLogEndpoints removes the delivery destination and delivery source of a log
+// type. Parts that no longer exist are skipped.
+func deleteAuditLogEndpoints(ctx p.Context, logsclient *cloudwatchlogs.Client, name string) error {
+	var notFound *logstypes.ResourceNotFoundException
+
+	_, err := logsclient.DeleteDeliveryDestination(ctx, &cloudwatchlogs.DeleteDeliveryDestinationInput{Name: &name})
+	if err != nil && !errors.As(err, &notFound) {
+		return err
+	}
+	_, err = logsclient.DeleteDeliverySource(ctx, &cloudwatchlogs.DeleteDeliverySourceInput{Name: &name})
+	if err != nil && !errors.As(err, &notFound) {
+		return err
+	}
+	return nil
+}
+
+func (AuditLogConfiguration) Read(ctx p.Context, id string, inputs AuditLogConfigurationArgs, state AuditLogConfigurationState) (string, AuditLogConfigurationArgs, AuditLogConfigurationState, error) {
+	// Create the CloudWatch Logs service client for the region
+	logsclient, err := logsClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	deliveries := []AuditLogDelivery{}
+	for _, log := range auditLogs(&state.AuditLogConfigurationArgs) {
+		delivery, found := findAuditLogDelivery(state.Deliveries, log.logType)
+		if !found {
+			*log.destination = nil
+			continue
+		}
+
+		_, err := logsclient.GetDelivery(ctx, &cloudwatchlogs.GetDeliveryInput{Id: &delivery.DeliveryId})
+		var notFound *logstypes.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			// The delivery has been deleted outside of pulumi.
+			*log.destination = nil
+			continue
+		}
+		if err != nil {
+			return "", inputs, state, err
+		}
+
+		name := state.OrganizationId + "-" + log.name
+		destination, err := logsclient.GetDeliveryDestination(ctx, &cloudwatchlogs.GetDeliveryDestinationInput{Name: &name})
+		if err != nil {
+			return "", inputs, state, err
+		}
+		if destination.DeliveryDestination.DeliveryDestinationConfiguration != nil {
+			delivery.DestinationArn = ifNotNil(destination.DeliveryDestination.DeliveryDestinationConfiguration.DestinationResourceArn, "")
+		}
+		*log.destination = &delivery.DestinationArn
+		deliveries = append(deliveries, delivery)
+	}
+	state.Deliveries = deliveries
+
+	return id, state.AuditLogConfigurationArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (AuditLogConfiguration) Delete(ctx p.Context, id string, props AuditLogConfigurationState) error {
+	// Create the CloudWatch Logs service client for the region
+	logsclient, err := logsClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	for _, log := range auditLogs(&props.AuditLogConfigurationArgs) {
+		delivery, found := findAuditLogDelivery(props.Deliveries, log.logType)
+		if !found {
+			continue
+		}
+		err = disableAuditLog(ctx, logsclient, props.OrganizationId, log, delivery.DeliveryId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type AvailabilityConfiguration struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type AvailabilityConfigurationArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the availability configuration is created in.
+	OrganizationId string `pulumi:"organizationId"`
+	// The domain whose free/busy information is looked up through the provider.
+	DomainName string `pulumi:"domainName"`
+	// The idempotency token associated with the request.
+	ClientToken *string `pulumi:"clientToken,optional"`
+	// The EWS endpoint of an Exchange availability provider. Either the EWS provider
+	// (ewsEndpoint, ewsUsername, ewsPassword) or lambdaArn must be specified.
+	EwsEndpoint *string `pulumi:"ewsEndpoint,optional"`
+	// The username used to authenticate against the EWS endpoint.
+	EwsUsername *string `pulumi:"ewsUsername,optional"`
+	// The password used to authenticate against the EWS endpoint.
+	EwsPassword *string `pulumi:"ewsPassword,optional" provider:"secret"`
+	// The ARN of the Lambda function of a custom availability provider.
+	LambdaArn *string `pulumi:"lambdaArn,optional"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type AvailabilityConfigurationState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	AvailabilityConfigurationArgs
+
+	// The type of the availability provider, EWS or LAMBDA.
+	ProviderType string `pulumi:"providerType"`
+}
+
+// availabilityProviders returns the EWS or Lambda provider configured by the arguments.
+func availabilityProviders(ewsEndpoint, ewsUsername, ewsPassword, lambdaArn *string) (*types.EwsAvailabilityProvider, *types.LambdaAvailabilityProvider, error) {
+	isEws := ewsEndpoint != nil || ewsUsername != nil || ewsPassword != nil
+	if isEws == (lambdaArn != nil) {
+		return nil, nil, errors.New("either the EWS provider (ewsEndpoint, ewsUsername, ewsPassword) or lambdaArn must be specified")
+	}
+	if lambdaArn != nil {
+		return nil, &types.LambdaAvailabilityProvider{LambdaArn: lambdaArn}, nil
+	}
+	if ewsEndpoint == nil || ewsUsername == nil || ewsPassword == nil {
+		return nil, nil, errors.New("ewsEndpoint, ewsUsername and ewsPassword must all be specified for an EWS provider")
+	}
+	return &types.EwsAvailabilityProvider{
+		EwsEndpoint: ewsEndpoint,
+		EwsUsername: ewsUsername,
+		EwsPassword: ewsPassword,
+	}, nil, nil
+}
+
+// Check validates the inputs before they are used.
+func (AvailabilityConfiguration) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (AvailabilityConfigurationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args AvailabilityConfigurationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("domainName", args.DomainName, validateDomainName)
+		c.checkOptional("lambdaArn", args.LambdaArn, validateLambdaArn)
+
+		// Only which properties are set matters, so values unknown at preview time count.
+		set := func(property string) *string {
+			if c.set(property) {
+				return new(string)
+			}
+			return nil
+		}
+		_, _, err := availabilityProviders(set("ewsEndpoint"), set("ewsUsername"), set("ewsPassword"), set("lambdaArn"))
+		if c.set("lambdaArn") {
+			c.fail("lambdaArn", err)
+		} else {
+			c.fail("ewsEndpoint", err)
+		}
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (AvailabilityConfiguration) Create(ctx p.Context, name string, input AvailabilityConfigurationArgs, preview bool) (string, AvailabilityConfigurationState, error) {
+	state := AvailabilityConfigurationState{AvailabilityConfigurationArgs: input}
+	ewsProvider, lambdaProvider, err := availabilityProviders(input.EwsEndpoint, input.EwsUsername, input.EwsPassword, input.LambdaArn)
+	if err != nil {
+		return "", state, err
+	}
+	state.ProviderType = availabilityProviderType(ewsProvider)
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	_, err = workmailclient.CreateAvailabilityConfiguration(ctx, &workmail.CreateAvailabilityConfigurationInput{
+		OrganizationId: &input.OrganizationId,
+		DomainName:     &input.DomainName,
+		ClientToken:    input.ClientToken,
+		EwsProvider:    ewsProvider,
+		LambdaProvider: lambdaProvider,
+	})
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.DomainName, state, nil
+}
+
+func availabilityProviderType(ewsProvider *types.EwsAvailabilityProvider) string {
+	if ewsProvider != nil {
+		return string(types.AvailabilityProviderTypeEws)
+	}
+	return string(types.AvailabilityProviderTypeLambda)
+}
+
+func (AvailabilityConfiguration) Diff(ctx p.Context, id string, olds AvailabilityConfigurationState, news AvailabilityConfigurationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  DomainName
+	if olds.DomainName != news.DomainName {
+		diffs["domainName"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  EwsEndpoint
+	if ptrDiff(olds.EwsEndpoint, news.EwsEndpoint) {
+		diffs["ewsEndpoint"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  EwsUsername
+	if ptrDiff(olds.EwsUsername, news.EwsUsername) {
+		diffs["ewsUsername"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  EwsPassword
+	if ptrDiff(olds.EwsPassword, news.EwsPassword) {
+		diffs["ewsPassword"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  LambdaArn
+	if ptrDiff(olds.LambdaArn, news.LambdaArn) {
+		diffs["lambdaArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	// A domain has a single availability configuration, so the old one has to go first.
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+func (AvailabilityConfiguration) Update(ctx p.Context, id string, olds AvailabilityConfigurationState, news AvailabilityConfigurationArgs, preview bool) (AvailabilityConfigurationState, error) {
+	state := AvailabilityConfigurationState{AvailabilityConfigurationArgs: news}
+	ewsProvider, lambdaProvider, err := availabilityProviders(news.EwsEndpoint, news.EwsUsername, news.EwsPassword, news.LambdaArn)
+	if err != nil {
+		return state, err
+	}
+	state.ProviderType = availabilityProviderType(ewsProvider)
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	_, err = workmailclient.UpdateAvailabilityConfiguration(ctx, &workmail.UpdateAvailabilityConfigurationInput{
+		OrganizationId: &news.OrganizationId,
+		DomainName:     &news.DomainName,
+		EwsProvider:    ewsProvider,
+		LambdaProvider: lambdaProvider,
+	})
+
+	return state, err
+}
+
+func (AvailabilityConfiguration) Read(ctx p.Context, id string, inputs AvailabilityConfigurationArgs, state AvailabilityConfigurationState) (string, AvailabilityConfigurationArgs, AvailabilityConfigurationState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	paginator := workmail.NewListAvailabilityConfigurationsPaginator(workmailclient, &workmail.ListAvailabilityConfigurationsInput{
+		OrganizationId: &state.OrganizationId,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", inputs, state, err
+		}
+		availability, found := Find(func(availability types.AvailabilityConfiguration) bool {
+			return availability.DomainName != nil && *availability.DomainName == id
+		})(page.AvailabilityConfigurations)
+		if !found {
+			continue
+		}
+
+		// The EWS password is never returned, so the one in state is kept.
+		state.DomainName = id
+		state.ProviderType = string(availability.ProviderType)
+		if availability.EwsProvider != nil {
+			state.EwsEndpoint = availability.EwsProvider.EwsEndpoint
+			state.EwsUsername = availability.EwsProvider.EwsUsername
+			state.LambdaArn = nil
+		}
+		if availability.LambdaProvider != nil {
+			state.LambdaArn = availability.LambdaProvider.LambdaArn
+			state.EwsEndpoint = nil
+			state.EwsUsername = nil
+			state.EwsPassword = nil
+		}
+		return id, state.AvailabilityConfigurationArgs, state, nil
+	}
+
+	// The configuration has been deleted outside of pulumi.
+	return "", inputs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (AvailabilityConfiguration) Delete(ctx p.Context, id string, props AvailabilityConfigurationState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteAvailabilityConfiguration(ctx, &workmail.DeleteAvailabilityConfigurationInput{
+		OrganizationId: &props.OrganizationId,
+		DomainName:     &props.DomainName,
+	})
+
+	return err
+}
@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// inputChecker collects the validation failures of the inputs of a resource, so all of
+// them are shown at preview time instead of failing mid-deployment.
+type inputChecker struct {
+	inputs   resource.PropertyMap
+	failures []p.CheckFailure
+}
+
+// checkInputs decodes the inputs and runs the checks of the resource on them.
+func checkInputs[I any](inputs resource.PropertyMap, check func(c *inputChecker, args I)) (I, []p.CheckFailure, error) {
+	args, failures, err := infer.DefaultCheck[I](inputs)
+	if err != nil || len(failures) > 0 {
+		return args, failures, err
+	}
+
+	c := &inputChecker{inputs: inputs}
+	check(c, args)
+	return args, c.failures, nil
+}
+
+// known reports whether the property is set to a value known at preview time. Values of
+// other resources' outputs are only checked once they are known.
+func (c *inputChecker) known(property string) bool {
+	value, ok := c.inputs[resource.PropertyKey(property)]
+	return ok && !value.IsNull() && !value.ContainsUnknowns()
+}
+
+// set reports whether the property is set, possibly to a value that is unknown at
+// preview time.
+func (c *inputChecker) set(property string) bool {
+	value, ok := c.inputs[resource.PropertyKey(property)]
+	return ok && !value.IsNull()
+}
+
+func (c *inputChecker) fail(property string, err error) {
+	if err != nil {
+		c.failures = append(c.failures, p.CheckFailure{Property: property, Reason: err.Error()})
+	}
+}
+
+// check validates a required property.
+func (c *inputChecker) check(property string, value string, validate func(string) error) {
+	if c.known(property) {
+		c.fail(property, validate(value))
+	}
+}
+
+// checkOptional validates an optional property if it is set.
+func (c *inputChecker) checkOptional(property string, value *string, validate func(string) error) {
+	if value != nil && c.known(property) {
+		c.fail(property, validate(*value))
+	}
+}
+
+// checkAll validates a property with a validator of the whole inputs.
+func (c *inputChecker) checkAll(property string, validate func() error) {
+	if c.known(property) {
+		c.fail(property, validate())
+	}
+}
+
+var (
+	regionPattern       = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	aliasPattern        = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	userNamePattern     = regexp.MustCompile(`^[\w\-.]+(@[a-zA-Z0-9.\-]+\.[a-zA-Z0-9-]{2,})?$`)
+	localPartPattern    = regexp.MustCompile("^[a-zA-Z0-9!#$%&'*+/=?^_`{|}~-]+(\\.[a-zA-Z0-9!#$%&'*+/=?^_`{|}~-]+)*$")
+	domainNamePattern   = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+	userPoolIdPattern   = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+_[0-9a-zA-Z]+$`)
+	emailAddressPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+)
+
+// validateRegion accepts AWS regions like eu-west-1. An empty region falls back to the
+// region of the provider.
+func validateRegion(region string) error {
+	if region != "" && !regionPattern.MatchString(region) {
+		return fmt.Errorf("%q is not an AWS region, e.g. eu-west-1", region)
+	}
+	return nil
+}
+
+// validateAlias accepts WorkMail organization aliases, which become the subdomain of the
+// organization's awsapps.com domain.
+func validateAlias(alias string) error {
+	switch {
+	case len(alias) < 1 || len(alias) > 62:
+		return fmt.Errorf("alias %q must be between 1 and 62 characters long", alias)
+	case !aliasPattern.MatchString(alias):
+		return fmt.Errorf("alias %q may only contain letters, digits and hyphens, and must start and end with a letter or digit", alias)
+	case strings.HasPrefix(alias, "d-"):
+		return fmt.Errorf("alias %q must not start with d-, which is reserved for directory ids", alias)
+	}
+	return nil
+}
+
+// validateUserName accepts user names of at most 64 characters, the limit of the WorkMail
+// directory. Users of AD Connector and Simple AD directories are further limited to 20
+// characters by the directory.
+func validateUserName(name string) error {
+	switch {
+	case len(name) < 1 || len(name) > 64:
+		return fmt.Errorf("user name %q must be between 1 and 64 characters long", name)
+	case !userNamePattern.MatchString(name):
+		return fmt.Errorf("user name %q may only contain letters, digits, underscores, hyphens and periods", name)
+	}
+	return nil
+}
+
+// validateEmailPrefix accepts the local part of an email address, the part before the @.
+func validateEmailPrefix(prefix string) error {
+	switch {
+	case len(prefix) < 1 || len(prefix) > 64:
+		return fmt.Errorf("email prefix %q must be between 1 and 64 characters long", prefix)
+	case !localPartPattern.MatchString(prefix):
+		return fmt.Errorf("email prefix %q is not a valid email address local part", prefix)
+	}
+	return nil
+}
+
+func validateEmailAddress(email string) error {
+	if !emailAddressPattern.MatchString(email) {
+		return fmt.Errorf("%q is not a valid email address", email)
+	}
+	return validateDomainName(email[strings.LastIndex(email, "@")+1:])
+}
+
+func validateDomainName(domain string) error {
+	if len(domain) > 253 || !domainNamePattern.MatchString(domain) {
+		return fmt.Errorf("%q is not a valid domain name", domain)
+	}
+	return nil
+}
+
+func validateUserPoolId(userPoolId string) error {
+	if !userPoolIdPattern.MatchString(userPoolId) {
+		return fmt.Errorf("%q is not a cognito user pool id, e.g. eu-west-1_AbCdEf123", userPoolId)
+	}
+	return nil
+}
+
+// validateArn returns a validator that accepts ARNs of the service whose resource starts
+// with one of the resource prefixes, e.g. validateArn("lambda", "function:").
+func validateArn(description, service string, resourcePrefixes ...string) func(string) error {
+	return func(value string) error {
+		parsed, err := arn.Parse(value)
+		if err != nil || parsed.Service != service {
+			return fmt.Errorf("%q is not %s ARN", value, description)
+		}
+		for _, prefix := range resourcePrefixes {
+			if strings.HasPrefix(parsed.Resource, prefix) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not %s ARN", value, description)
+	}
+}
+
+var (
+	validateKmsKeyArn      = validateArn("a KMS key", "kms", "key/")
+	validateLambdaArn      = validateArn("a Lambda function", "lambda", "function:")
+	validateRoleArn        = validateArn("an IAM role", "iam", "role/")
+	validateLogGroupArn    = validateArn("a CloudWatch Logs log group", "logs", "log-group:")
+	validateSesIdentityArn = validateArn("an SES identity", "ses", "identity/")
+	validateInstanceArn    = validateArn("an IAM Identity Center instance", "sso", "instance/")
+	validateApplicationArn = validateArn("an IAM Identity Center application", "sso", "application/")
+)
+
+// checkOrganizationOrDomain requires organizationId or domain to be set.
+func (c *inputChecker) checkOrganizationOrDomain(organizationId, domain *string) {
+	if !c.set("organizationId") && !c.set("domain") {
+		c.fail("organizationId", fmt.Errorf("either organizationId or domain must be specified"))
+	}
+	c.checkOptional("domain", domain, validateDomainName)
+}
@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/smithy-go/middleware"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// LoadAWSConfig loads the AWS configuration all service clients are created from.
+// Tests can replace it to point the clients at stubs.
+var LoadAWSConfig = config.LoadDefaultConfig
+
+// defaultMaxAttempts is the number of attempts of a throttled or failed request when the
+// provider config does not set maxAttempts.
+const defaultMaxAttempts = 5
+
+// clientCache creates AWS service clients once per region and profile and shares them
+// between all resource operations of the provider.
+type clientCache struct {
+	profile     string
+	region      string
+	maxAttempts int
+	limiter     *rateLimiter
+
+	mu      sync.Mutex
+	configs map[string]aws.Config
+	clients map[string]any
+}
+
+func newClientCache(c *Config) *clientCache {
+	cache := &clientCache{
+		maxAttempts: defaultMaxAttempts,
+		limiter:     newRateLimiter(c),
+		configs:     map[string]aws.Config{},
+		clients:     map[string]any{},
+	}
+	if c != nil {
+		cache.profile = ifNotNil(c.Profile, "")
+		cache.region = ifNotNil(c.Region, "")
+		cache.maxAttempts = ifNotNil(c.MaxAttempts, defaultMaxAttempts)
+	}
+	return cache
+}
+
+// defaultClients is used when the provider has not been configured.
+var defaultClients = newClientCache(nil)
+
+// providerClients returns the client cache of the configured provider.
+func providerClients(ctx p.Context) *clientCache {
+	if c := infer.GetConfig[*Config](ctx); c != nil && c.clients != nil {
+		return c.clients
+	}
+	return defaultClients
+}
+
+// awsConfig returns the AWS config for the region. An empty region falls back to the
+// region of the provider config, then to the region of the AWS configuration.
+func (cache *clientCache) awsConfig(ctx p.Context, region string) (aws.Config, error) {
+	if region == "" {
+		region = cache.region
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cfg, ok := cache.configs[region]; ok {
+		return cfg, nil
+	}
+
+	userAgentVersion := Version
+	if userAgentVersion == "" {
+		userAgentVersion = "dev"
+	}
+	options := []func(*config.LoadOptions) error{
+		config.WithRetryer(func() aws.Retryer {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, func(o *retry.StandardOptions) {
+					o.MaxAttempts = cache.maxAttempts
+					// Throttled requests are always retried, the rate limiter spreads them out.
+					o.RateLimiter = ratelimit.None
+				})
+			})
+		}),
+		config.WithAPIOptions([]func(*middleware.Stack) error{
+			awsmiddleware.AddUserAgentKeyValue("pulumi-"+Name, userAgentVersion),
+		}),
+	}
+	if cache.profile != "" {
+		options = append(options, config.WithSharedConfigProfile(cache.profile))
+	}
+	if region != "" {
+		options = append(options, config.WithRegion(region))
+	}
+
+	cfg, err := LoadAWSConfig(ctx, options...)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.APIOptions = append(cfg.APIOptions, cache.limiter.middleware(cfg.Region))
+	cache.configs[region] = cfg
+	return cfg, nil
+}
+
+// cachedClient returns the client of the service for the region, creating it on first use.
+func cachedClient[C any](ctx p.Context, service, region string, newClient func(aws.Config) C) (C, error) {
+	cache := providerClients(ctx)
+	cfg, err := cache.awsConfig(ctx, region)
+	if err != nil {
+		var zero C
+		return zero, err
+	}
+
+	key := fmt.Sprintf("%s/%s", service, cfg.Region)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if client, ok := cache.clients[key]; ok {
+		return client.(C), nil
+	}
+	client := newClient(cfg)
+	cache.clients[key] = client
+	return client, nil
+}
+
+func workmailClient(ctx p.Context, region *string) (*workmail.Client, error) {
+	return cachedClient(ctx, "workmail", ifNotNil(region, ""), func(cfg aws.Config) *workmail.Client {
+		return workmail.NewFromConfig(cfg, func(o *workmail.Options) {
+			o.APIOptions = append(o.APIOptions, addErrorTranslation)
+			// The codes are only classified for WorkMail, other services may reuse them.
+			o.Retryer = retry.AddWithErrorCodes(o.Retryer, retryableErrorCodes()...)
+		})
+	})
+}
+
+func cognitoClient(ctx p.Context, region *string) (*cognitoidentityprovider.Client, error) {
+	return cachedClient(ctx, "cognito-idp", ifNotNil(region, ""), func(cfg aws.Config) *cognitoidentityprovider.Client {
+		return cognitoidentityprovider.NewFromConfig(cfg)
+	})
+}
+
+func logsClient(ctx p.Context, region *string) (*cloudwatchlogs.Client, error) {
+	return cachedClient(ctx, "logs", ifNotNil(region, ""), func(cfg aws.Config) *cloudwatchlogs.Client {
+		return cloudwatchlogs.NewFromConfig(cfg)
+	})
+}
+
+func lambdaClient(ctx p.Context, region *string) (*lambda.Client, error) {
+	return cachedClient(ctx, "lambda", ifNotNil(region, ""), func(cfg aws.Config) *lambda.Client {
+		return lambda.NewFromConfig(cfg)
+	})
+}
+
+func kmsClient(ctx p.Context, region *string) (*kms.Client, error) {
+	return cachedClient(ctx, "kms", ifNotNil(region, ""), func(cfg aws.Config) *kms.Client {
+		return kms.NewFromConfig(cfg)
+	})
+}
+
+func sesClient(ctx p.Context, region string) (*sesv2.Client, error) {
+	return cachedClient(ctx, "ses", region, func(cfg aws.Config) *sesv2.Client {
+		return sesv2.NewFromConfig(cfg)
+	})
+}
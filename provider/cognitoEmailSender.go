@@ -1,10 +1,14 @@
 package provider
 
 import (
-	"github.com/aws/aws-sdk-go-v2/config"
+	"errors"
+	"fmt"
+
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
 // Each resource has a controlling struct.
@@ -21,18 +25,81 @@ type CognitoEmailSender struct{}
 
 // Each resource has an input struct, defining what arguments it accepts.
 type CognitoEmailSenderArgs struct {
+	// The AWS Region of the user pool. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
 	// ID of the cognito user pool that should be updated with the custom email sender.
 	UserPoolId string `pulumi:"userPoolId"`
 	// Arn of the lambda that is responsible for sending emails
 	LambdaArn string `pulumi:"lambdaArn"`
 	// Arn of the KMS key that is used to encrypt verification codes sent via email.
 	KmsKeyArn string `pulumi:"kmsKeyArn"`
+	// The version of the lambda trigger event. Defaults to V1_0.
+	LambdaVersion *CognitoLambdaVersion `pulumi:"lambdaVersion,optional"`
+	// The attributes cognito verifies automatically by sending a code. When omitted, the
+	// auto-verified attributes of the user pool are left untouched. Attributes users sign
+	// in with must be included.
+	AutoVerifiedAttributes []CognitoVerifiedAttribute `pulumi:"autoVerifiedAttributes,optional"`
+	// If enabled, the lambda is granted permission to be invoked by the user pool, and the
+	// policy of the KMS key is checked to let cognito encrypt codes.
+	ManagePermissions *bool `pulumi:"managePermissions,optional"`
+}
+
+type CognitoLambdaVersion string
+
+const (
+	CognitoLambdaVersionV1_0 CognitoLambdaVersion = "V1_0"
+)
+
+func (CognitoLambdaVersion) Values() []infer.EnumValue[CognitoLambdaVersion] {
+	return []infer.EnumValue[CognitoLambdaVersion]{
+		{Name: "V1_0", Value: CognitoLambdaVersionV1_0, Description: "Version 1.0 of the custom sender trigger event."},
+	}
+}
+
+type CognitoVerifiedAttribute string
+
+const (
+	CognitoVerifiedAttributeEmail       CognitoVerifiedAttribute = "email"
+	CognitoVerifiedAttributePhoneNumber CognitoVerifiedAttribute = "phone_number"
+)
+
+func (CognitoVerifiedAttribute) Values() []infer.EnumValue[CognitoVerifiedAttribute] {
+	return []infer.EnumValue[CognitoVerifiedAttribute]{
+		{Name: "Email", Value: CognitoVerifiedAttributeEmail, Description: "The email address is verified automatically."},
+		{Name: "PhoneNumber", Value: CognitoVerifiedAttributePhoneNumber, Description: "The phone number is verified automatically."},
+	}
 }
 
 // Each resource has a state, describing the fields that exist on the created resource.
 type CognitoEmailSenderState struct {
 	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
 	CognitoEmailSenderArgs
+
+	// The custom email sender of the user pool before it was replaced, restored on delete.
+	PreviousCustomEmailSender *CognitoCustomSenderConfig `pulumi:"previousCustomEmailSender,optional"`
+	// The KMS key of the user pool before it was replaced, restored on delete.
+	PreviousKmsKeyArn *string `pulumi:"previousKmsKeyArn,optional"`
+	// The auto-verified attributes of the user pool before they were replaced, restored on
+	// delete or once autoVerifiedAttributes is no longer managed. Only recorded while
+	// autoVerifiedAttributes is managed.
+	PreviousAutoVerifiedAttributes []string `pulumi:"previousAutoVerifiedAttributes,optional"`
+}
+
+type CognitoCustomSenderConfig struct {
+	// Arn of the lambda.
+	LambdaArn string `pulumi:"lambdaArn"`
+	// The version of the lambda trigger event.
+	LambdaVersion string `pulumi:"lambdaVersion"`
+}
+
+// Check validates the inputs before they are used.
+func (CognitoEmailSender) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (CognitoEmailSenderArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args CognitoEmailSenderArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("userPoolId", args.UserPoolId, validateUserPoolId)
+		c.check("lambdaArn", args.LambdaArn, validateLambdaArn)
+		c.check("kmsKeyArn", args.KmsKeyArn, validateKmsKeyArn)
+	})
 }
 
 // All resources must implement Create at a minimum.
@@ -42,26 +109,12 @@ func (CognitoEmailSender) Create(ctx p.Context, name string, input CognitoEmailS
 		return name, state, nil
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cognitoclient, err := cognitoClient(ctx, input.Region)
 	if err != nil {
 		return "", state, err
 	}
 
-	cognitoclient := cognitoidentityprovider.NewFromConfig(cfg)
-
-	_, err = cognitoclient.UpdateUserPool(ctx, &cognitoidentityprovider.UpdateUserPoolInput{
-		UserPoolId: &input.UserPoolId,
-		LambdaConfig: &types.LambdaConfigType{
-			CustomEmailSender: &types.CustomEmailLambdaVersionConfigType{
-				LambdaVersion: "V1_0",
-				LambdaArn:     &input.LambdaArn,
-			},
-			KMSKeyID: &input.KmsKeyArn,
-		},
-		AutoVerifiedAttributes: []types.VerifiedAttributeType{
-			"email",
-		},
-	})
+	err = putCustomEmailSender(ctx, cognitoclient, &state, true)
 	if err != nil {
 		return "", state, err
 	}
@@ -69,41 +122,284 @@ func (CognitoEmailSender) Create(ctx p.Context, name string, input CognitoEmailS
 	return input.LambdaArn, state, nil
 }
 
+// putCustomEmailSender sets the custom email sender of the user pool and keeps the rest
+// of its configuration. On create, the replaced lambda config is recorded in the state.
+func putCustomEmailSender(ctx p.Context, cognitoclient *cognitoidentityprovider.Client, state *CognitoEmailSenderState, create bool) error {
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &state.UserPoolId,
+	})
+	if err != nil {
+		return err
+	}
+
+	update := userPoolUpdate(pool.UserPool)
+	if create {
+		if sender := update.LambdaConfig.CustomEmailSender; sender != nil {
+			state.PreviousCustomEmailSender = &CognitoCustomSenderConfig{
+				LambdaArn:     ifNotNil(sender.LambdaArn, ""),
+				LambdaVersion: string(sender.LambdaVersion),
+			}
+		}
+		state.PreviousKmsKeyArn = update.LambdaConfig.KMSKeyID
+	}
+
+	err = ensureSharedKmsKey(state.UserPoolId, update.LambdaConfig.CustomSMSSender != nil, update.LambdaConfig.KMSKeyID, state.KmsKeyArn)
+	if err != nil {
+		return err
+	}
+
+	if ifNotNil(state.ManagePermissions, false) {
+		err = validateCognitoKmsKey(ctx, state.Region, state.KmsKeyArn)
+		if err != nil {
+			return err
+		}
+		err = addCognitoInvokePermission(ctx, state.Region, state.LambdaArn, state.UserPoolId, ifNotNil(pool.UserPool.Arn, ""))
+		if err != nil {
+			return err
+		}
+	}
+
+	update.LambdaConfig.CustomEmailSender = &types.CustomEmailLambdaVersionConfigType{
+		LambdaVersion: types.CustomEmailSenderLambdaVersionType(ifNotNil(state.LambdaVersion, CognitoLambdaVersionV1_0)),
+		LambdaArn:     &state.LambdaArn,
+	}
+	update.LambdaConfig.KMSKeyID = &state.KmsKeyArn
+
+	if state.AutoVerifiedAttributes != nil {
+		err = validateAutoVerifiedAttributes(pool.UserPool, state.AutoVerifiedAttributes)
+		if err != nil {
+			return err
+		}
+		if state.PreviousAutoVerifiedAttributes == nil {
+			state.PreviousAutoVerifiedAttributes = Map(func(attribute types.VerifiedAttributeType) string { return string(attribute) })(update.AutoVerifiedAttributes)
+		}
+		update.AutoVerifiedAttributes = Map(func(attribute CognitoVerifiedAttribute) types.VerifiedAttributeType {
+			return types.VerifiedAttributeType(attribute)
+		})(state.AutoVerifiedAttributes)
+	} else if state.PreviousAutoVerifiedAttributes != nil {
+		// The attributes are no longer managed, so the ones from before are restored.
+		update.AutoVerifiedAttributes = Map(func(attribute string) types.VerifiedAttributeType { return types.VerifiedAttributeType(attribute) })(state.PreviousAutoVerifiedAttributes)
+		state.PreviousAutoVerifiedAttributes = nil
+	}
+
+	_, err = cognitoclient.UpdateUserPool(ctx, update)
+	return err
+}
+
+// userPoolUpdate returns an update that keeps the current configuration of the user pool.
+// UpdateUserPool resets every setting that is omitted, so it must always start from the
+// described pool.
+func userPoolUpdate(pool *types.UserPoolType) *cognitoidentityprovider.UpdateUserPoolInput {
+	update := &cognitoidentityprovider.UpdateUserPoolInput{
+		UserPoolId:                  pool.Id,
+		AccountRecoverySetting:      pool.AccountRecoverySetting,
+		AdminCreateUserConfig:       pool.AdminCreateUserConfig,
+		AutoVerifiedAttributes:      pool.AutoVerifiedAttributes,
+		DeletionProtection:          pool.DeletionProtection,
+		DeviceConfiguration:         pool.DeviceConfiguration,
+		EmailConfiguration:          pool.EmailConfiguration,
+		EmailVerificationMessage:    pool.EmailVerificationMessage,
+		EmailVerificationSubject:    pool.EmailVerificationSubject,
+		LambdaConfig:                pool.LambdaConfig,
+		MfaConfiguration:            pool.MfaConfiguration,
+		Policies:                    pool.Policies,
+		SmsAuthenticationMessage:    pool.SmsAuthenticationMessage,
+		SmsConfiguration:            pool.SmsConfiguration,
+		SmsVerificationMessage:      pool.SmsVerificationMessage,
+		UserAttributeUpdateSettings: pool.UserAttributeUpdateSettings,
+		UserPoolAddOns:              pool.UserPoolAddOns,
+		UserPoolTags:                pool.UserPoolTags,
+		VerificationMessageTemplate: pool.VerificationMessageTemplate,
+	}
+	if update.LambdaConfig == nil {
+		update.LambdaConfig = &types.LambdaConfigType{}
+	}
+	if pool.AdminCreateUserConfig != nil {
+		// The deprecated UnusedAccountValidityDays is still described, but UpdateUserPool
+		// rejects it next to the TemporaryPasswordValidityDays of the password policy.
+		config := *pool.AdminCreateUserConfig
+		config.UnusedAccountValidityDays = 0
+		update.AdminCreateUserConfig = &config
+	}
+	return update
+}
+
+// validateAutoVerifiedAttributes ensures the attributes users sign in with are verified
+// automatically, otherwise users signing up can never confirm their account by themselves.
+func validateAutoVerifiedAttributes(pool *types.UserPoolType, attributes []CognitoVerifiedAttribute) error {
+	signInAttributes := Map(func(attribute types.UsernameAttributeType) string { return string(attribute) })(pool.UsernameAttributes)
+	for _, attribute := range pool.AliasAttributes {
+		if attribute != types.AliasAttributeTypePreferredUsername {
+			signInAttributes = append(signInAttributes, string(attribute))
+		}
+	}
+	for _, signInAttribute := range signInAttributes {
+		_, found := Find(func(attribute CognitoVerifiedAttribute) bool { return string(attribute) == signInAttribute })(attributes)
+		if !found {
+			return fmt.Errorf("users of user pool %s sign in with %s, so autoVerifiedAttributes must include it", ifNotNil(pool.Id, ""), signInAttribute)
+		}
+	}
+	return nil
+}
+
+// ensureSharedKmsKey ensures a custom sender uses the same KMS key as the other custom sender
+// of the user pool, since both senders share the single KMS key of the pool.
+func ensureSharedKmsKey(userPoolId string, otherSender bool, currentKmsKeyArn *string, kmsKeyArn string) error {
+	if otherSender && currentKmsKeyArn != nil && *currentKmsKeyArn != kmsKeyArn {
+		return fmt.Errorf("user pool %s encrypts codes of its other custom sender with KMS key %s, custom email and SMS senders must use the same key", userPoolId, *currentKmsKeyArn)
+	}
+	return nil
+}
+
 func (CognitoEmailSender) Diff(ctx p.Context, id string, olds CognitoEmailSenderState, news CognitoEmailSenderArgs) (p.DiffResponse, error) {
 	diffs := make(map[string]p.PropertyDiff)
-	hasChanges := false
 
-	if true {
-		diffs["lambdaArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
-		hasChanges = true
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
 	}
 
+	//  UserPoolId
 	if olds.UserPoolId != news.UserPoolId {
 		diffs["userPoolId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
-		hasChanges = true
 	}
 
+	//  LambdaArn
+	if olds.LambdaArn != news.LambdaArn {
+		diffs["lambdaArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  KmsKeyArn
 	if olds.KmsKeyArn != news.KmsKeyArn {
-		diffs["kmsKeyArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
-		hasChanges = true
+		diffs["kmsKeyArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  LambdaVersion
+	if ptrDiff(olds.LambdaVersion, news.LambdaVersion) {
+		diffs["lambdaVersion"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  AutoVerifiedAttributes
+	if setDiff(olds.AutoVerifiedAttributes, news.AutoVerifiedAttributes) || (olds.AutoVerifiedAttributes == nil) != (news.AutoVerifiedAttributes == nil) {
+		diffs["autoVerifiedAttributes"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  ManagePermissions
+	if ptrDiff(olds.ManagePermissions, news.ManagePermissions) {
+		diffs["managePermissions"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+// Update switches the lambda and KMS key of the sender without removing it in between.
+func (CognitoEmailSender) Update(ctx p.Context, id string, olds CognitoEmailSenderState, news CognitoEmailSenderArgs, preview bool) (CognitoEmailSenderState, error) {
+	state := olds
+	state.CognitoEmailSenderArgs = news
+	if olds.AutoVerifiedAttributes == nil {
+		// Record the auto-verified attributes once they become managed.
+		state.PreviousAutoVerifiedAttributes = nil
+	}
+	if preview {
+		return state, nil
 	}
 
-	return p.DiffResponse{HasChanges: hasChanges, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+	cognitoclient, err := cognitoClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	err = putCustomEmailSender(ctx, cognitoclient, &state, false)
+	if err != nil {
+		return state, err
+	}
+
+	// Clean up the permission of the lambda that is no longer used.
+	if ifNotNil(olds.ManagePermissions, false) && (olds.LambdaArn != news.LambdaArn || !ifNotNil(news.ManagePermissions, false)) {
+		err = removeCognitoInvokePermission(ctx, olds.Region, olds.LambdaArn, olds.UserPoolId)
+	}
+
+	return state, err
+}
+
+// Read reports the sender as deleted when the user pool or its custom email sender is gone,
+// and picks up lambda and KMS key changes made outside of pulumi.
+func (CognitoEmailSender) Read(ctx p.Context, id string, inputs CognitoEmailSenderArgs, state CognitoEmailSenderState) (string, CognitoEmailSenderArgs, CognitoEmailSenderState, error) {
+	cognitoclient, err := cognitoClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &state.UserPoolId,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	lambdaConfig := pool.UserPool.LambdaConfig
+	if lambdaConfig == nil || lambdaConfig.CustomEmailSender == nil {
+		return "", inputs, state, nil
+	}
+
+	state.LambdaArn = ifNotNil(lambdaConfig.CustomEmailSender.LambdaArn, "")
+	state.KmsKeyArn = ifNotNil(lambdaConfig.KMSKeyID, "")
+	if state.LambdaVersion != nil {
+		version := CognitoLambdaVersion(lambdaConfig.CustomEmailSender.LambdaVersion)
+		state.LambdaVersion = &version
+	}
+	if state.AutoVerifiedAttributes != nil {
+		state.AutoVerifiedAttributes = Map(func(attribute types.VerifiedAttributeType) CognitoVerifiedAttribute {
+			return CognitoVerifiedAttribute(attribute)
+		})(pool.UserPool.AutoVerifiedAttributes)
+	}
+
+	return id, state.CognitoEmailSenderArgs, state, nil
 }
 
 // The Delete method will run when the resource is deleted.
 func (CognitoEmailSender) Delete(ctx p.Context, id string, props CognitoEmailSenderState) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cognitoclient, err := cognitoClient(ctx, props.Region)
 	if err != nil {
 		return err
 	}
-	cognitoclient := cognitoidentityprovider.NewFromConfig(cfg)
 
-	_, err = cognitoclient.UpdateUserPool(ctx, &cognitoidentityprovider.UpdateUserPoolInput{
-		UserPoolId:             &props.UserPoolId,
-		LambdaConfig:           nil,
-		AutoVerifiedAttributes: []types.VerifiedAttributeType{},
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &props.UserPoolId,
 	})
+	if err != nil {
+		return err
+	}
+
+	// Restore the lambda config from before the sender was created.
+	update := userPoolUpdate(pool.UserPool)
+	update.LambdaConfig.CustomEmailSender = nil
+	if previous := props.PreviousCustomEmailSender; previous != nil {
+		update.LambdaConfig.CustomEmailSender = &types.CustomEmailLambdaVersionConfigType{
+			LambdaArn:     &previous.LambdaArn,
+			LambdaVersion: types.CustomEmailSenderLambdaVersionType(previous.LambdaVersion),
+		}
+	}
+	// The KMS key is shared with the custom SMS sender.
+	if update.LambdaConfig.CustomSMSSender == nil {
+		update.LambdaConfig.KMSKeyID = props.PreviousKmsKeyArn
+	}
+	if props.AutoVerifiedAttributes != nil {
+		update.AutoVerifiedAttributes = Map(func(attribute string) types.VerifiedAttributeType { return types.VerifiedAttributeType(attribute) })(props.PreviousAutoVerifiedAttributes)
+	}
+
+	_, err = cognitoclient.UpdateUserPool(ctx, update)
+	if err != nil {
+		return err
+	}
+
+	if ifNotNil(props.ManagePermissions, false) {
+		err = removeCognitoInvokePermission(ctx, props.Region, props.LambdaArn, props.UserPoolId)
+	}
 
 	return err
 }
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+)
+
+const cognitoServicePrincipal = "cognito-idp.amazonaws.com"
+
+// cognitoInvokeStatementId is the id of the lambda policy statement that lets the user pool
+// invoke its custom sender.
+func cognitoInvokeStatementId(userPoolId string) string {
+	return "cognito-email-sender-" + userPoolId
+}
+
+// addCognitoInvokePermission allows the user pool to invoke the lambda. An existing
+// statement is kept as is.
+func addCognitoInvokePermission(ctx p.Context, region *string, lambdaArn, userPoolId, userPoolArn string) error {
+	lambdaclient, err := lambdaClient(ctx, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = lambdaclient.AddPermission(ctx, &lambda.AddPermissionInput{
+		FunctionName: &lambdaArn,
+		StatementId:  aws.String(cognitoInvokeStatementId(userPoolId)),
+		Action:       aws.String("lambda:InvokeFunction"),
+		Principal:    aws.String(cognitoServicePrincipal),
+		SourceArn:    &userPoolArn,
+	})
+	var conflict *lambdatypes.ResourceConflictException
+	if errors.As(err, &conflict) {
+		return nil
+	}
+	return err
+}
+
+// removeCognitoInvokePermission removes the statement added by addCognitoInvokePermission.
+func removeCognitoInvokePermission(ctx p.Context, region *string, lambdaArn, userPoolId string) error {
+	lambdaclient, err := lambdaClient(ctx, region)
+	if err != nil {
+		return err
+	}
+
+	_, err = lambdaclient.RemovePermission(ctx, &lambda.RemovePermissionInput{
+		FunctionName: &lambdaArn,
+		StatementId:  aws.String(cognitoInvokeStatementId(userPoolId)),
+	})
+	var notFound *lambdatypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// validateCognitoKmsKey ensures the key policy lets cognito encrypt codes with the key.
+// Key policies that cannot be read only cause a warning, since the deploying role may lack
+// kms:GetKeyPolicy.
+func validateCognitoKmsKey(ctx p.Context, region *string, kmsKeyArn string) error {
+	kmsclient, err := kmsClient(ctx, region)
+	if err != nil {
+		return err
+	}
+
+	key, err := kmsclient.DescribeKey(ctx, &kms.DescribeKeyInput{
+		KeyId: &kmsKeyArn,
+	})
+	if err != nil {
+		return fmt.Errorf("KMS key %s: %w", kmsKeyArn, err)
+	}
+
+	policy, err := kmsclient.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{
+		KeyId:      key.KeyMetadata.KeyId,
+		PolicyName: aws.String("default"),
+	})
+	if err != nil {
+		ctx.Logf(diag.Warning, "could not verify that cognito may use KMS key %s: %s", kmsKeyArn, err)
+		return nil
+	}
+
+	allowed, err := keyPolicyAllowsCognito(ifNotNil(policy.Policy, ""))
+	if err != nil {
+		return fmt.Errorf("KMS key %s: %w", kmsKeyArn, err)
+	}
+	if !allowed {
+		return fmt.Errorf("the policy of KMS key %s must allow %s to use kms:Encrypt and kms:CreateGrant", kmsKeyArn, cognitoServicePrincipal)
+	}
+	return nil
+}
+
+type keyPolicy struct {
+	Statement []json.RawMessage
+}
+
+type keyPolicyStatement struct {
+	Effect string
+	// Principal is either "*" or an object like {"Service": ...} or {"AWS": ...}.
+	Principal json.RawMessage
+	Action    stringOrSlice
+}
+
+// allowsCognito reports whether the principal of the statement includes cognito. Principals
+// of other forms are skipped.
+func (statement keyPolicyStatement) allowsCognito() bool {
+	var everyone string
+	if json.Unmarshal(statement.Principal, &everyone) == nil {
+		return everyone == "*"
+	}
+
+	var principal struct {
+		Service stringOrSlice
+		AWS     stringOrSlice
+	}
+	if json.Unmarshal(statement.Principal, &principal) != nil {
+		return false
+	}
+	isCognito := func(service string) bool { return service == cognitoServicePrincipal }
+	isEveryone := func(aws string) bool { return aws == "*" }
+	_, cognito := Find(isCognito)(principal.Service)
+	_, all := Find(isEveryone)(principal.AWS)
+	return cognito || all
+}
+
+// stringOrSlice decodes policy elements that are either a string or a list of strings.
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var value string
+	if json.Unmarshal(data, &value) == nil {
+		*s = []string{value}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// keyPolicyAllowsCognito reports whether the key policy allows cognito to encrypt with the
+// key. Statements that cannot be read are skipped instead of failing the whole check.
+func keyPolicyAllowsCognito(document string) (bool, error) {
+	var policy keyPolicy
+	err := json.Unmarshal([]byte(document), &policy)
+	if err != nil {
+		return false, err
+	}
+
+	allowed := map[string]bool{}
+	for _, raw := range policy.Statement {
+		var statement keyPolicyStatement
+		if json.Unmarshal(raw, &statement) != nil || statement.Effect != "Allow" || !statement.allowsCognito() {
+			continue
+		}
+		for _, action := range statement.Action {
+			allowed[action] = true
+		}
+	}
+	return allowed["*"] || allowed["kms:*"] || (allowed["kms:Encrypt"] && allowed["kms:CreateGrant"]), nil
+}
@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type CognitoSmsSender struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type CognitoSmsSenderArgs struct {
+	// The AWS Region of the user pool. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// ID of the cognito user pool that should be updated with the custom SMS sender.
+	UserPoolId string `pulumi:"userPoolId"`
+	// Arn of the lambda that is responsible for sending SMS messages
+	LambdaArn string `pulumi:"lambdaArn"`
+	// Arn of the KMS key that is used to encrypt verification codes sent via SMS. Must be the
+	// same key as the one of a CognitoEmailSender on the same user pool.
+	KmsKeyArn string `pulumi:"kmsKeyArn"`
+	// The version of the lambda trigger event. Defaults to V1_0.
+	LambdaVersion *CognitoLambdaVersion `pulumi:"lambdaVersion,optional"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type CognitoSmsSenderState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	CognitoSmsSenderArgs
+
+	// The custom SMS sender of the user pool before it was replaced, restored on delete.
+	PreviousCustomSmsSender *CognitoCustomSenderConfig `pulumi:"previousCustomSmsSender,optional"`
+	// The KMS key of the user pool before it was replaced, restored on delete.
+	PreviousKmsKeyArn *string `pulumi:"previousKmsKeyArn,optional"`
+}
+
+// Check validates the inputs before they are used.
+func (CognitoSmsSender) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (CognitoSmsSenderArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args CognitoSmsSenderArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("userPoolId", args.UserPoolId, validateUserPoolId)
+		c.check("lambdaArn", args.LambdaArn, validateLambdaArn)
+		c.check("kmsKeyArn", args.KmsKeyArn, validateKmsKeyArn)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (CognitoSmsSender) Create(ctx p.Context, name string, input CognitoSmsSenderArgs, preview bool) (string, CognitoSmsSenderState, error) {
+	state := CognitoSmsSenderState{CognitoSmsSenderArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	cognitoclient, err := cognitoClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	err = putCustomSmsSender(ctx, cognitoclient, &state, true)
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.LambdaArn, state, nil
+}
+
+// putCustomSmsSender sets the custom SMS sender of the user pool and keeps the rest
+// of its configuration. On create, the replaced lambda config is recorded in the state.
+func putCustomSmsSender(ctx p.Context, cognitoclient *cognitoidentityprovider.Client, state *CognitoSmsSenderState, create bool) error {
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &state.UserPoolId,
+	})
+	if err != nil {
+		return err
+	}
+
+	update := userPoolUpdate(pool.UserPool)
+	if create {
+		if sender := update.LambdaConfig.CustomSMSSender; sender != nil {
+			state.PreviousCustomSmsSender = &CognitoCustomSenderConfig{
+				LambdaArn:     ifNotNil(sender.LambdaArn, ""),
+				LambdaVersion: string(sender.LambdaVersion),
+			}
+		}
+		state.PreviousKmsKeyArn = update.LambdaConfig.KMSKeyID
+	}
+
+	err = ensureSharedKmsKey(state.UserPoolId, update.LambdaConfig.CustomEmailSender != nil, update.LambdaConfig.KMSKeyID, state.KmsKeyArn)
+	if err != nil {
+		return err
+	}
+
+	update.LambdaConfig.CustomSMSSender = &types.CustomSMSLambdaVersionConfigType{
+		LambdaVersion: types.CustomSMSSenderLambdaVersionType(ifNotNil(state.LambdaVersion, CognitoLambdaVersionV1_0)),
+		LambdaArn:     &state.LambdaArn,
+	}
+	update.LambdaConfig.KMSKeyID = &state.KmsKeyArn
+
+	_, err = cognitoclient.UpdateUserPool(ctx, update)
+	return err
+}
+
+func (CognitoSmsSender) Diff(ctx p.Context, id string, olds CognitoSmsSenderState, news CognitoSmsSenderArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  UserPoolId
+	if olds.UserPoolId != news.UserPoolId {
+		diffs["userPoolId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  LambdaArn
+	if olds.LambdaArn != news.LambdaArn {
+		diffs["lambdaArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  KmsKeyArn
+	if olds.KmsKeyArn != news.KmsKeyArn {
+		diffs["kmsKeyArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  LambdaVersion
+	if ptrDiff(olds.LambdaVersion, news.LambdaVersion) {
+		diffs["lambdaVersion"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+// Update switches the lambda and KMS key of the sender without removing it in between.
+func (CognitoSmsSender) Update(ctx p.Context, id string, olds CognitoSmsSenderState, news CognitoSmsSenderArgs, preview bool) (CognitoSmsSenderState, error) {
+	state := olds
+	state.CognitoSmsSenderArgs = news
+	if preview {
+		return state, nil
+	}
+
+	cognitoclient, err := cognitoClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	err = putCustomSmsSender(ctx, cognitoclient, &state, false)
+
+	return state, err
+}
+
+// Read reports the sender as deleted when the user pool or its custom SMS sender is gone,
+// and picks up lambda and KMS key changes made outside of pulumi.
+func (CognitoSmsSender) Read(ctx p.Context, id string, inputs CognitoSmsSenderArgs, state CognitoSmsSenderState) (string, CognitoSmsSenderArgs, CognitoSmsSenderState, error) {
+	cognitoclient, err := cognitoClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &state.UserPoolId,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	lambdaConfig := pool.UserPool.LambdaConfig
+	if lambdaConfig == nil || lambdaConfig.CustomSMSSender == nil {
+		return "", inputs, state, nil
+	}
+
+	state.LambdaArn = ifNotNil(lambdaConfig.CustomSMSSender.LambdaArn, "")
+	state.KmsKeyArn = ifNotNil(lambdaConfig.KMSKeyID, "")
+	if state.LambdaVersion != nil {
+		version := CognitoLambdaVersion(lambdaConfig.CustomSMSSender.LambdaVersion)
+		state.LambdaVersion = &version
+	}
+
+	return id, state.CognitoSmsSenderArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (CognitoSmsSender) Delete(ctx p.Context, id string, props CognitoSmsSenderState) error {
+	cognitoclient, err := cognitoClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &props.UserPoolId,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Restore the lambda config from before the sender was created.
+	update := userPoolUpdate(pool.UserPool)
+	update.LambdaConfig.CustomSMSSender = nil
+	if previous := props.PreviousCustomSmsSender; previous != nil {
+		update.LambdaConfig.CustomSMSSender = &types.CustomSMSLambdaVersionConfigType{
+			LambdaArn:     &previous.LambdaArn,
+			LambdaVersion: types.CustomSMSSenderLambdaVersionType(previous.LambdaVersion),
+		}
+	}
+	// The KMS key is shared with the custom email sender.
+	if update.LambdaConfig.CustomEmailSender == nil {
+		update.LambdaConfig.KMSKeyID = props.PreviousKmsKeyArn
+	}
+
+	_, err = cognitoclient.UpdateUserPool(ctx, update)
+
+	return err
+}
@@ -0,0 +1,313 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+//
+// A CognitoWorkmailEmailConfiguration lets a user pool send its emails through SES from
+// the address of a WorkMail user. Deleting it restores the email configuration the pool
+// had before.
+type CognitoWorkmailEmailConfiguration struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type CognitoWorkmailEmailConfigurationArgs struct {
+	// The AWS Region of the user pool and the WorkMail organization. Defaults to the region
+	// of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// ID of the cognito user pool that sends emails from the WorkMail address.
+	UserPoolId string `pulumi:"userPoolId"`
+	// The organization of the WorkMail user.
+	OrganizationId string `pulumi:"organizationId"`
+	// The id of the WorkMail user whose address emails are sent from. The user must be
+	// registered to WorkMail.
+	UserId string `pulumi:"userId"`
+	// The ARN of the verified SES identity of the WorkMail domain or address.
+	SesIdentityArn string `pulumi:"sesIdentityArn"`
+	// The sender name shown to recipients, e.g. "Example Support".
+	FromName *string `pulumi:"fromName,optional"`
+	// The address replies are sent to. Defaults to the WorkMail address.
+	ReplyToAddress *string `pulumi:"replyToAddress,optional"`
+	// The SES configuration set used to send emails.
+	ConfigurationSet *string `pulumi:"configurationSet,optional"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type CognitoWorkmailEmailConfigurationState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	CognitoWorkmailEmailConfigurationArgs
+
+	// The address emails are sent from.
+	FromAddress string `pulumi:"fromAddress"`
+	// The email configuration of the user pool before it was replaced, restored on delete.
+	PreviousEmailConfiguration *CognitoEmailConfiguration `pulumi:"previousEmailConfiguration,optional"`
+}
+
+type CognitoEmailConfiguration struct {
+	// Whether cognito sends emails with its default email account or through SES.
+	EmailSendingAccount string `pulumi:"emailSendingAccount"`
+	// The ARN of the SES identity emails are sent from.
+	SourceArn *string `pulumi:"sourceArn,optional"`
+	// The sender address, optionally with a sender name.
+	From *string `pulumi:"from,optional"`
+	// The address replies are sent to.
+	ReplyToEmailAddress *string `pulumi:"replyToEmailAddress,optional"`
+	// The SES configuration set used to send emails.
+	ConfigurationSet *string `pulumi:"configurationSet,optional"`
+}
+
+// Check validates the inputs before they are used.
+func (CognitoWorkmailEmailConfiguration) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (CognitoWorkmailEmailConfigurationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args CognitoWorkmailEmailConfigurationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("userPoolId", args.UserPoolId, validateUserPoolId)
+		c.check("sesIdentityArn", args.SesIdentityArn, validateSesIdentityArn)
+		c.checkOptional("replyToAddress", args.ReplyToAddress, validateEmailAddress)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (CognitoWorkmailEmailConfiguration) Create(ctx p.Context, name string, input CognitoWorkmailEmailConfigurationArgs, preview bool) (string, CognitoWorkmailEmailConfigurationState, error) {
+	state := CognitoWorkmailEmailConfigurationState{CognitoWorkmailEmailConfigurationArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	err := putCognitoWorkmailEmailConfiguration(ctx, &state, true)
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.UserPoolId, state, nil
+}
+
+// putCognitoWorkmailEmailConfiguration resolves the address of the WorkMail user, ensures
+// SES may send from it and sets the email configuration of the user pool, keeping the rest
+// of its configuration. On create, the replaced email configuration is recorded in the state.
+func putCognitoWorkmailEmailConfiguration(ctx p.Context, state *CognitoWorkmailEmailConfigurationState, create bool) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return err
+	}
+
+	user, err := workmailclient.DescribeUser(ctx, &workmail.DescribeUserInput{
+		OrganizationId: &state.OrganizationId,
+		UserId:         &state.UserId,
+	})
+	if err != nil {
+		return err
+	}
+	if user.Email == nil {
+		return fmt.Errorf("user %s has no email address, it must be registered to WorkMail", state.UserId)
+	}
+	state.FromAddress = *user.Email
+
+	// The identity may live in another SES region than the user pool.
+	identityArn, err := arn.Parse(state.SesIdentityArn)
+	if err != nil {
+		return fmt.Errorf("sesIdentityArn: %w", err)
+	}
+	identity := strings.TrimPrefix(identityArn.Resource, "identity/")
+	sesclient, err := sesClient(ctx, identityArn.Region)
+	if err != nil {
+		return err
+	}
+
+	emailIdentity, err := sesclient.GetEmailIdentity(ctx, &sesv2.GetEmailIdentityInput{
+		EmailIdentity: &identity,
+	})
+	if err != nil {
+		return err
+	}
+	if !emailIdentity.VerifiedForSendingStatus {
+		return fmt.Errorf("SES identity %s is not verified for sending", identity)
+	}
+	if identity != state.FromAddress && !strings.HasSuffix(state.FromAddress, "@"+identity) {
+		return fmt.Errorf("SES identity %s does not cover the address %s of user %s", identity, state.FromAddress, state.UserId)
+	}
+
+	from := state.FromAddress
+	if state.FromName != nil {
+		from = fmt.Sprintf("%s <%s>", *state.FromName, state.FromAddress)
+	}
+	replyTo := ifNotNil(state.ReplyToAddress, state.FromAddress)
+
+	cognitoclient, err := cognitoClient(ctx, state.Region)
+	if err != nil {
+		return err
+	}
+
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &state.UserPoolId,
+	})
+	if err != nil {
+		return err
+	}
+
+	update := userPoolUpdate(pool.UserPool)
+	if create && update.EmailConfiguration != nil {
+		state.PreviousEmailConfiguration = &CognitoEmailConfiguration{
+			EmailSendingAccount: string(update.EmailConfiguration.EmailSendingAccount),
+			SourceArn:           update.EmailConfiguration.SourceArn,
+			From:                update.EmailConfiguration.From,
+			ReplyToEmailAddress: update.EmailConfiguration.ReplyToEmailAddress,
+			ConfigurationSet:    update.EmailConfiguration.ConfigurationSet,
+		}
+	}
+	update.EmailConfiguration = &types.EmailConfigurationType{
+		EmailSendingAccount: types.EmailSendingAccountTypeDeveloper,
+		SourceArn:           &state.SesIdentityArn,
+		From:                &from,
+		ReplyToEmailAddress: &replyTo,
+		ConfigurationSet:    state.ConfigurationSet,
+	}
+
+	_, err = cognitoclient.UpdateUserPool(ctx, update)
+	return err
+}
+
+func (CognitoWorkmailEmailConfiguration) Diff(ctx p.Context, id string, olds CognitoWorkmailEmailConfigurationState, news CognitoWorkmailEmailConfigurationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  UserPoolId
+	if olds.UserPoolId != news.UserPoolId {
+		diffs["userPoolId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  UserId
+	if olds.UserId != news.UserId {
+		diffs["userId"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  SesIdentityArn
+	if olds.SesIdentityArn != news.SesIdentityArn {
+		diffs["sesIdentityArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  FromName
+	if ptrDiff(olds.FromName, news.FromName) {
+		diffs["fromName"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  ReplyToAddress
+	if ptrDiff(olds.ReplyToAddress, news.ReplyToAddress) {
+		diffs["replyToAddress"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  ConfigurationSet
+	if ptrDiff(olds.ConfigurationSet, news.ConfigurationSet) {
+		diffs["configurationSet"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+func (CognitoWorkmailEmailConfiguration) Update(ctx p.Context, id string, olds CognitoWorkmailEmailConfigurationState, news CognitoWorkmailEmailConfigurationArgs, preview bool) (CognitoWorkmailEmailConfigurationState, error) {
+	state := olds
+	state.CognitoWorkmailEmailConfigurationArgs = news
+	if preview {
+		return state, nil
+	}
+
+	err := putCognitoWorkmailEmailConfiguration(ctx, &state, false)
+
+	return state, err
+}
+
+// Read reports the configuration as deleted when the user pool no longer sends from the
+// SES identity.
+func (CognitoWorkmailEmailConfiguration) Read(ctx p.Context, id string, inputs CognitoWorkmailEmailConfigurationArgs, state CognitoWorkmailEmailConfigurationState) (string, CognitoWorkmailEmailConfigurationArgs, CognitoWorkmailEmailConfigurationState, error) {
+	cognitoclient, err := cognitoClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &state.UserPoolId,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	emailConfiguration := pool.UserPool.EmailConfiguration
+	if emailConfiguration == nil || emailConfiguration.EmailSendingAccount != types.EmailSendingAccountTypeDeveloper {
+		return "", inputs, state, nil
+	}
+
+	state.SesIdentityArn = ifNotNil(emailConfiguration.SourceArn, "")
+	state.ConfigurationSet = emailConfiguration.ConfigurationSet
+	if state.ReplyToAddress != nil {
+		state.ReplyToAddress = emailConfiguration.ReplyToEmailAddress
+	}
+
+	return id, state.CognitoWorkmailEmailConfigurationArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (CognitoWorkmailEmailConfiguration) Delete(ctx p.Context, id string, props CognitoWorkmailEmailConfigurationState) error {
+	cognitoclient, err := cognitoClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	pool, err := cognitoclient.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: &props.UserPoolId,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Restore the email configuration from before, or the default cognito email account.
+	update := userPoolUpdate(pool.UserPool)
+	update.EmailConfiguration = &types.EmailConfigurationType{
+		EmailSendingAccount: types.EmailSendingAccountTypeCognitoDefault,
+	}
+	if previous := props.PreviousEmailConfiguration; previous != nil {
+		update.EmailConfiguration = &types.EmailConfigurationType{
+			EmailSendingAccount: types.EmailSendingAccountType(previous.EmailSendingAccount),
+			SourceArn:           previous.SourceArn,
+			From:                previous.From,
+			ReplyToEmailAddress: previous.ReplyToEmailAddress,
+			ConfigurationSet:    previous.ConfigurationSet,
+		}
+	}
+
+	_, err = cognitoclient.UpdateUserPool(ctx, update)
+
+	return err
+}
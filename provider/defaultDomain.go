@@ -1,10 +1,11 @@
 package provider
 
 import (
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/workmail"
 	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
 // Each resource has a controlling struct.
@@ -21,8 +22,8 @@ type DefaultDomain struct{}
 
 // Each resource has an input struct, defining what arguments it accepts.
 type DefaultDomainArgs struct {
-	// The AWS Region. TODO: This should be passed as a pulumi.Provider
-	Region string `pulumi:"region"`
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
 	// The domain name.
 	DomainName string `pulumi:"domainName"`
 	// The organization the domain should be associated with.
@@ -46,6 +47,14 @@ type DnsRecord struct {
 	Value    string `pulumi:"value"`
 }
 
+// Check validates the inputs before they are used.
+func (DefaultDomain) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (DefaultDomainArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args DefaultDomainArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("domainName", args.DomainName, validateDomainName)
+	})
+}
+
 // All resources must implement Create at a minimum.
 func (DefaultDomain) Create(ctx p.Context, name string, input DefaultDomainArgs, preview bool) (string, DefaultDomainState, error) {
 	state := DefaultDomainState{DefaultDomainArgs: input}
@@ -53,14 +62,11 @@ func (DefaultDomain) Create(ctx p.Context, name string, input DefaultDomainArgs,
 		return name, state, nil
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
 	if err != nil {
 		return "", state, err
 	}
-	cfg.Region = input.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	_, err = workmailclient.RegisterMailDomain(ctx, &workmail.RegisterMailDomainInput{
 		DomainName:     &input.DomainName,
@@ -87,27 +93,19 @@ func (DefaultDomain) Create(ctx p.Context, name string, input DefaultDomainArgs,
 		return "", state, err
 	}
 
-	state.Records = Map(func(record types.DnsRecord) DnsRecord {
-		return DnsRecord{
-			Type:     *record.Type,
-			Hostname: *record.Hostname,
-			Value:    *record.Value,
-		}
-	})(mailDomain.Records)
+	state.Records = Map(toDnsRecord)(mailDomain.Records)
+	logMailDomainVerification(ctx, input.DomainName, mailDomain)
 
 	return state.DomainName, state, nil
 }
 
 // The Delete method will run when the resource is deleted.
 func (DefaultDomain) Delete(ctx p.Context, id string, props DefaultDomainState) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
 	if err != nil {
 		return err
 	}
-	cfg.Region = props.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	// Reset the default domain
 	organization, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
@@ -135,3 +133,14 @@ func (DefaultDomain) Delete(ctx p.Context, id string, props DefaultDomainState)
 
 	return err
 }
+
+// logMailDomainVerification reports the verification of the domain. Until the DNS records
+// are created, mail to the domain is not delivered to WorkMail.
+func logMailDomainVerification(ctx p.Context, domainName string, mailDomain *workmail.GetMailDomainOutput) {
+	if mailDomain.OwnershipVerificationStatus == types.DnsRecordVerificationStatusVerified && mailDomain.DkimVerificationStatus == types.DnsRecordVerificationStatusVerified {
+		ctx.LogStatusf(diag.Info, "mail domain %s is verified", domainName)
+		return
+	}
+	ctx.LogStatusf(diag.Info, "mail domain %s awaits verification (ownership %s, DKIM %s), create the %d DNS records of the default domain",
+		domainName, mailDomain.OwnershipVerificationStatus, mailDomain.DkimVerificationStatus, len(mailDomain.Records))
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type EmailMonitoringConfiguration struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type EmailMonitoringConfigurationArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization whose email is monitored. An organization has a single email
+	// monitoring configuration.
+	OrganizationId string `pulumi:"organizationId"`
+	// The Amazon Resource Name (ARN) of the IAM role WorkMail assumes to write to the log group.
+	RoleArn string `pulumi:"roleArn"`
+	// The Amazon Resource Name (ARN) of the CloudWatch Logs log group email events are written to.
+	LogGroupArn string `pulumi:"logGroupArn"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type EmailMonitoringConfigurationState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	EmailMonitoringConfigurationArgs
+}
+
+// Check validates the inputs before they are used.
+func (EmailMonitoringConfiguration) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (EmailMonitoringConfigurationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args EmailMonitoringConfigurationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("roleArn", args.RoleArn, validateRoleArn)
+		c.check("logGroupArn", args.LogGroupArn, validateLogGroupArn)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (EmailMonitoringConfiguration) Create(ctx p.Context, name string, input EmailMonitoringConfigurationArgs, preview bool) (string, EmailMonitoringConfigurationState, error) {
+	state := EmailMonitoringConfigurationState{EmailMonitoringConfigurationArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	err := putEmailMonitoringConfiguration(ctx, input)
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.OrganizationId, state, nil
+}
+
+func (EmailMonitoringConfiguration) Diff(ctx p.Context, id string, olds EmailMonitoringConfigurationState, news EmailMonitoringConfigurationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  RoleArn
+	if olds.RoleArn != news.RoleArn {
+		diffs["roleArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  LogGroupArn
+	if olds.LogGroupArn != news.LogGroupArn {
+		diffs["logGroupArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+func (EmailMonitoringConfiguration) Update(ctx p.Context, id string, olds EmailMonitoringConfigurationState, news EmailMonitoringConfigurationArgs, preview bool) (EmailMonitoringConfigurationState, error) {
+	state := EmailMonitoringConfigurationState{EmailMonitoringConfigurationArgs: news}
+	if preview {
+		return state, nil
+	}
+
+	// PutEmailMonitoringConfiguration overwrites the existing configuration.
+	err := putEmailMonitoringConfiguration(ctx, news)
+
+	return state, err
+}
+
+func putEmailMonitoringConfiguration(ctx p.Context, input EmailMonitoringConfigurationArgs) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.PutEmailMonitoringConfiguration(ctx, &workmail.PutEmailMonitoringConfigurationInput{
+		OrganizationId: &input.OrganizationId,
+		RoleArn:        &input.RoleArn,
+		LogGroupArn:    &input.LogGroupArn,
+	})
+
+	return err
+}
+
+func (EmailMonitoringConfiguration) Read(ctx p.Context, id string, inputs EmailMonitoringConfigurationArgs, state EmailMonitoringConfigurationState) (string, EmailMonitoringConfigurationArgs, EmailMonitoringConfigurationState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	monitoring, err := workmailclient.DescribeEmailMonitoringConfiguration(ctx, &workmail.DescribeEmailMonitoringConfigurationInput{
+		OrganizationId: &id,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		// The configuration has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.OrganizationId = id
+	state.RoleArn = ifNotNil(monitoring.RoleArn, "")
+	state.LogGroupArn = ifNotNil(monitoring.LogGroupArn, "")
+
+	return id, state.EmailMonitoringConfigurationArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (EmailMonitoringConfiguration) Delete(ctx p.Context, id string, props EmailMonitoringConfigurationState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteEmailMonitoringConfiguration(ctx, &workmail.DeleteEmailMonitoringConfigurationInput{
+		OrganizationId: &props.OrganizationId,
+	})
+
+	return err
+}
@@ -0,0 +1,242 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// requestFailed returns an error for a failure AWS reported in a successful response, e.g.
+// the failed state of an asynchronous operation. Errors of failed requests already include
+// the request id, this adds it to errors created from responses, so failures can be
+// traced in AWS support tickets.
+func requestFailed(metadata middleware.Metadata, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if requestId, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		return fmt.Errorf("%w, RequestID: %s", err, requestId)
+	}
+	return err
+}
+
+// WorkmailError is a WorkMail error translated into what went wrong for the resource and
+// how to fix it. It wraps the SDK error, so errors.As still finds the WorkMail exception.
+type WorkmailError struct {
+	// What went wrong, e.g. "name info is already taken in organization m-123".
+	Message string
+	// How to fix it.
+	Hint string
+	// Whether retrying the request later may succeed.
+	Retryable bool
+	// The id of the failed AWS request.
+	RequestID string
+
+	apiErr smithy.APIError
+	err    error
+}
+
+func (e *WorkmailError) Error() string {
+	message := e.Message
+	if e.Hint != "" {
+		message += ": " + e.Hint
+	}
+	message += fmt.Sprintf(" [%s: %s", e.apiErr.ErrorCode(), e.apiErr.ErrorMessage())
+	if e.RequestID != "" {
+		message += ", RequestID: " + e.RequestID
+	}
+	return message + "]"
+}
+
+func (e *WorkmailError) Unwrap() error {
+	return e.err
+}
+
+// workmailErrorInput holds the fields of the failed request a translation refers to.
+type workmailErrorInput struct {
+	organization string
+	entity       string
+	name         string
+	email        string
+	domain       string
+}
+
+type workmailErrorTranslation struct {
+	message   func(workmailErrorInput) string
+	hint      string
+	retryable bool
+}
+
+// workmailErrors translates the WorkMail exceptions by error code. Exceptions without a
+// translation are returned as is.
+var workmailErrors = map[string]workmailErrorTranslation{
+	"DirectoryServiceAuthenticationFailedException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("the directory of organization %s rejected the WorkMail credentials", in.organization)
+		},
+		hint: "check the service account of the AD Connector",
+	},
+	"DirectoryUnavailableException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("the directory of organization %s is unavailable", in.organization)
+		},
+		hint:      "retry once the directory is reachable again",
+		retryable: true,
+	},
+	"EmailAddressInUseException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("email address %s is already in use in organization %s", in.email, in.organization)
+		},
+		hint: "remove it from the user, group or resource that uses it first",
+	},
+	"EntityAlreadyRegisteredException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("entity %s is already registered to WorkMail", in.entity)
+		},
+		hint: "import the existing registration or deregister the entity first",
+	},
+	"EntityNotFoundException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("entity %s not found in organization %s", in.entity, in.organization)
+		},
+		hint: "check that it exists and has not been deleted outside of pulumi",
+	},
+	"EntityStateException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("entity %s is not in a state that allows this operation", in.entity)
+		},
+		hint: "users, groups and resources must be deregistered from WorkMail before they are deleted, and deleted entities can no longer be changed",
+	},
+	"InvalidPasswordException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("the password of %s does not meet the password policy of organization %s", in.entity, in.organization)
+		},
+		hint: "use a longer password that mixes upper and lower case letters, digits and symbols",
+	},
+	"LimitExceededException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("a WorkMail quota of organization %s is exceeded", in.organization)
+		},
+		hint: "remove unused entities or request a quota increase",
+	},
+	"MailDomainInUseException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("mail domain %s is still in use in organization %s", in.domain, in.organization)
+		},
+		hint: "remove the email addresses on the domain and make another domain the default first",
+	},
+	"MailDomainNotFoundException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("mail domain %s not found in organization %s", in.domain, in.organization)
+		},
+		hint: "register the domain with a DefaultDomain resource first",
+	},
+	"MailDomainStateException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("mail domain %s of organization %s is not verified", in.domain, in.organization)
+		},
+		hint: "create the DNS records of the domain and retry once it is verified",
+	},
+	"NameAvailabilityException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("name %s is already taken in organization %s", in.name, in.organization)
+		},
+		hint: "choose another name or import the existing entity",
+	},
+	"OrganizationNotFoundException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("organization %s not found", in.organization)
+		},
+		hint: "check the organizationId and the region",
+	},
+	"OrganizationStateException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("organization %s is not active", in.organization)
+		},
+		hint:      "wait until the organization has been created, or check that it has not been deleted",
+		retryable: true,
+	},
+	"ReservedNameException": {
+		message: func(in workmailErrorInput) string {
+			return fmt.Sprintf("name %s is reserved by WorkMail", in.name)
+		},
+		hint: "choose another name",
+	},
+}
+
+// translateError translates WorkMail errors of the request with the given input into a
+// WorkmailError. All other errors are returned unchanged.
+func translateError(input any, err error) error {
+	var apiErr smithy.APIError
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+	translation, ok := workmailErrors[apiErr.ErrorCode()]
+	if !ok {
+		return err
+	}
+
+	translated := &WorkmailError{
+		Message: translation.message(workmailErrorInput{
+			organization: inputField(input, "OrganizationId"),
+			entity:       inputField(input, "EntityId", "UserId", "GroupId", "ResourceId", "MemberId"),
+			name:         inputField(input, "Name"),
+			email:        inputField(input, "Email", "PrimaryEmail"),
+			domain:       inputField(input, "DomainName"),
+		}),
+		Hint:      translation.hint,
+		Retryable: translation.retryable,
+		apiErr:    apiErr,
+		err:       err,
+	}
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		translated.RequestID = responseErr.ServiceRequestID()
+	}
+	return translated
+}
+
+// inputField returns the first set string field of the request input.
+func inputField(input any, fields ...string) string {
+	value := reflect.Indirect(reflect.ValueOf(input))
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, field := range fields {
+		fieldValue := value.FieldByName(field)
+		if !fieldValue.IsValid() {
+			continue
+		}
+		if s, ok := fieldValue.Interface().(*string); ok && s != nil {
+			return *s
+		}
+	}
+	return ""
+}
+
+// retryableErrorCodes returns the codes of the WorkMail errors that are classified as
+// retryable, so the retryer of the WorkMail client retries them like throttling errors.
+func retryableErrorCodes() []string {
+	codes := []string{}
+	for code, translation := range workmailErrors {
+		if translation.retryable {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// addErrorTranslation translates the errors of all WorkMail requests. It wraps the whole
+// operation, so only the error of the last attempt is translated.
+func addErrorTranslation(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("TranslateError", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		return out, metadata, translateError(in.Parameters, err)
+	}), middleware.Before)
+}
@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type GetAccessControlEffect struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type GetAccessControlEffectArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The access protocol action. Valid values include ActiveSync, AutoDiscover, EWS, IMAP,
+	// SMTP, WindowsOutlook, and WebMail.
+	Action string `pulumi:"action"`
+	// The IPv4 address the request originates from.
+	IpAddress string `pulumi:"ipAddress"`
+	// The user id.
+	UserId *string `pulumi:"userId,optional"`
+	// The impersonation role id.
+	ImpersonationRoleId *string `pulumi:"impersonationRoleId,optional"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type GetAccessControlEffectResult struct {
+	// The effective access, ALLOW or DENY.
+	Effect string `pulumi:"effect"`
+	// The names of the rules that match the given parameters, resulting in the effect.
+	MatchedRules []string `pulumi:"matchedRules"`
+}
+
+// Call evaluates the effective access control rules for a request.
+func (GetAccessControlEffect) Call(ctx p.Context, input GetAccessControlEffectArgs) (GetAccessControlEffectResult, error) {
+	result := GetAccessControlEffectResult{}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	effect, err := workmailclient.GetAccessControlEffect(ctx, &workmail.GetAccessControlEffectInput{
+		OrganizationId:      &input.OrganizationId,
+		Action:              &input.Action,
+		IpAddress:           &input.IpAddress,
+		UserId:              input.UserId,
+		ImpersonationRoleId: input.ImpersonationRoleId,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Effect = string(effect.Effect)
+	result.MatchedRules = effect.MatchedRules
+	if result.MatchedRules == nil {
+		result.MatchedRules = []string{}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type GetGroup struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type GetGroupArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The identifier of the organization the group belongs to. Either organizationId or
+	// domain must be specified. When both are specified, organizationId is ignored.
+	OrganizationId *string `pulumi:"organizationId,optional"`
+	// The mail domain of the organization the group belongs to. Either organizationId or
+	// domain must be specified. When both are specified, organizationId is ignored.
+	Domain *string `pulumi:"domain,optional"`
+	// The name of the group. Exactly one of name or email must be specified.
+	Name *string `pulumi:"name,optional"`
+	// The primary email address of the group. Exactly one of name or email must be specified.
+	Email *string `pulumi:"email,optional"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type GetGroupResult struct {
+	// The group id.
+	GroupId string `pulumi:"groupId"`
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The name of the group.
+	Name string `pulumi:"name"`
+	// The primary email address of the group.
+	Email *string `pulumi:"email,optional"`
+	// If enabled, the group is hidden from the address book.
+	HiddenFromGlobalAddressList bool `pulumi:"hiddenFromGlobalAddressList"`
+	// The state of the group: ENABLED (registered to WorkMail), DISABLED or DELETED.
+	State string `pulumi:"state"`
+	// The ids of the users and groups that are members of the group.
+	MemberIds []string `pulumi:"memberIds"`
+}
+
+// Call looks up an existing group by name or email.
+func (GetGroup) Call(ctx p.Context, input GetGroupArgs) (GetGroupResult, error) {
+	result := GetGroupResult{}
+
+	if (input.Name == nil) == (input.Email == nil) {
+		return result, errors.New("exactly one of name or email must be specified")
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	result.OrganizationId, err = resolveOrganizationId(ctx, workmailclient, input.OrganizationId, input.Domain)
+	if err != nil {
+		return result, err
+	}
+
+	// The list filters only match prefixes, so the exact match is done here.
+	groups := []types.Group{}
+	paginator := workmail.NewListGroupsPaginator(workmailclient, &workmail.ListGroupsInput{
+		OrganizationId: &result.OrganizationId,
+		Filters:        &types.ListGroupsFilters{NamePrefix: input.Name, PrimaryEmailPrefix: input.Email},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return result, err
+		}
+		groups = append(groups, page.Groups...)
+	}
+	group, found := Find(func(group types.Group) bool {
+		if input.Name != nil {
+			return group.Name != nil && *group.Name == *input.Name
+		}
+		return group.Email != nil && *group.Email == *input.Email
+	})(groups)
+	if !found {
+		return result, fmt.Errorf("no workmail group %s found in organization %s",
+			ifNotNil(input.Name, ifNotNil(input.Email, "")), result.OrganizationId)
+	}
+
+	details, err := workmailclient.DescribeGroup(ctx, &workmail.DescribeGroupInput{
+		OrganizationId: &result.OrganizationId,
+		GroupId:        group.Id,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.GroupId = ifNotNil(details.GroupId, "")
+	result.Name = ifNotNil(details.Name, "")
+	result.Email = details.Email
+	result.HiddenFromGlobalAddressList = details.HiddenFromGlobalAddressList
+	result.State = string(details.State)
+
+	result.MemberIds = []string{}
+	members := workmail.NewListGroupMembersPaginator(workmailclient, &workmail.ListGroupMembersInput{
+		OrganizationId: &result.OrganizationId,
+		GroupId:        details.GroupId,
+	})
+	for members.HasMorePages() {
+		page, err := members.NextPage(ctx)
+		if err != nil {
+			return result, err
+		}
+		result.MemberIds = append(result.MemberIds, Map(func(member types.Member) string {
+			return ifNotNil(member.Id, "")
+		})(page.Members)...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type GetMailDomain struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type GetMailDomainArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the domain is registered with.
+	OrganizationId string `pulumi:"organizationId"`
+	// The domain name.
+	DomainName string `pulumi:"domainName"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type GetMailDomainResult struct {
+	// Mail domain records.
+	Records []DnsRecord `pulumi:"records"`
+	// Whether the domain is the default mail domain of the organization.
+	IsDefault bool `pulumi:"isDefault"`
+	// Whether the domain is a test domain provided by WorkMail.
+	IsTestDomain bool `pulumi:"isTestDomain"`
+	// The verification status of the domain ownership (PENDING, VERIFIED or FAILED).
+	OwnershipVerificationStatus string `pulumi:"ownershipVerificationStatus"`
+	// The verification status of the DKIM records (PENDING, VERIFIED or FAILED).
+	DkimVerificationStatus string `pulumi:"dkimVerificationStatus"`
+}
+
+// Call looks up a mail domain registered with an organization.
+func (GetMailDomain) Call(ctx p.Context, input GetMailDomainArgs) (GetMailDomainResult, error) {
+	result := GetMailDomainResult{}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	mailDomain, err := workmailclient.GetMailDomain(ctx, &workmail.GetMailDomainInput{
+		OrganizationId: &input.OrganizationId,
+		DomainName:     &input.DomainName,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Records = Map(toDnsRecord)(mailDomain.Records)
+	result.IsDefault = mailDomain.IsDefault
+	result.IsTestDomain = mailDomain.IsTestDomain
+	result.OwnershipVerificationStatus = string(mailDomain.OwnershipVerificationStatus)
+	result.DkimVerificationStatus = string(mailDomain.DkimVerificationStatus)
+
+	return result, nil
+}
+
+func toDnsRecord(record types.DnsRecord) DnsRecord {
+	return DnsRecord{
+		Type:     *record.Type,
+		Hostname: *record.Hostname,
+		Value:    *record.Value,
+	}
+}
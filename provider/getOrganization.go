@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type GetOrganization struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type GetOrganizationArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization id. Exactly one of organizationId, alias or domain must be specified.
+	OrganizationId *string `pulumi:"organizationId,optional"`
+	// The organization alias. Exactly one of organizationId, alias or domain must be specified.
+	Alias *string `pulumi:"alias,optional"`
+	// The default mail domain of the organization. Exactly one of organizationId, alias or
+	// domain must be specified.
+	Domain *string `pulumi:"domain,optional"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type GetOrganizationResult struct {
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The Amazon Resource Name (ARN) of the organization.
+	Arn string `pulumi:"arn"`
+	// The organization alias.
+	Alias string `pulumi:"alias"`
+	// The state of the organization.
+	State string `pulumi:"state"`
+	// The default mail domain of the organization.
+	DefaultMailDomain string `pulumi:"defaultMailDomain"`
+	// The AWS Directory Service directory ID.
+	DirectoryId *string `pulumi:"directoryId,optional"`
+	// The type of directory associated with the organization.
+	DirectoryType *string `pulumi:"directoryType,optional"`
+	// Whether interoperability between WorkMail and Microsoft Exchange is enabled.
+	InteroperabilityEnabled bool `pulumi:"interoperabilityEnabled"`
+}
+
+// Call looks up an existing organization.
+func (GetOrganization) Call(ctx p.Context, input GetOrganizationArgs) (GetOrganizationResult, error) {
+	result := GetOrganizationResult{}
+
+	specified := 0
+	for _, lookup := range []*string{input.OrganizationId, input.Alias, input.Domain} {
+		if lookup != nil {
+			specified++
+		}
+	}
+	if specified != 1 {
+		return result, errors.New("exactly one of organizationId, alias or domain must be specified")
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	organizationId := input.OrganizationId
+	if input.Alias != nil {
+		organization, found, err := findOrganization(ctx, workmailclient, func(org types.OrganizationSummary) bool {
+			return org.Alias != nil && *org.Alias == *input.Alias
+		})
+		if err != nil {
+			return result, err
+		}
+		if !found {
+			return result, fmt.Errorf("no workmail organization with alias %s found", *input.Alias)
+		}
+		organizationId = organization.OrganizationId
+	}
+	if input.Domain != nil {
+		id, err := resolveOrganizationId(ctx, workmailclient, nil, input.Domain)
+		if err != nil {
+			return result, err
+		}
+		organizationId = &id
+	}
+
+	organization, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
+		OrganizationId: organizationId,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.OrganizationId = ifNotNil(organization.OrganizationId, "")
+	result.Arn = ifNotNil(organization.ARN, "")
+	result.Alias = ifNotNil(organization.Alias, "")
+	result.State = ifNotNil(organization.State, "")
+	result.DefaultMailDomain = ifNotNil(organization.DefaultMailDomain, "")
+	result.DirectoryId = organization.DirectoryId
+	result.DirectoryType = organization.DirectoryType
+	result.InteroperabilityEnabled = organization.InteroperabilityEnabled
+
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type GetUser struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type GetUserArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The identifier of the organization the user belongs to. Either organizationId or
+	// domain must be specified. When both are specified, organizationId is ignored.
+	OrganizationId *string `pulumi:"organizationId,optional"`
+	// The mail domain of the organization the user belongs to. Either organizationId or
+	// domain must be specified. When both are specified, organizationId is ignored.
+	Domain *string `pulumi:"domain,optional"`
+	// The name of the user. Exactly one of name or email must be specified.
+	Name *string `pulumi:"name,optional"`
+	// The primary email address of the user. Exactly one of name or email must be specified.
+	Email *string `pulumi:"email,optional"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type GetUserResult struct {
+	// The user id.
+	UserId string `pulumi:"userId"`
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The name of the user.
+	Name string `pulumi:"name"`
+	// The primary email address of the user.
+	Email *string `pulumi:"email,optional"`
+	// The display name of the user.
+	DisplayName *string `pulumi:"displayName,optional"`
+	// The first name of the user.
+	FirstName *string `pulumi:"firstName,optional"`
+	// The last name of the user.
+	LastName *string `pulumi:"lastName,optional"`
+	// If enabled, the user is hidden from the address book.
+	HiddenFromGlobalAddressList bool `pulumi:"hiddenFromGlobalAddressList"`
+	// The state of the user: ENABLED (registered to WorkMail), DISABLED or DELETED.
+	State string `pulumi:"state"`
+	// The role of the user.
+	UserRole string `pulumi:"userRole"`
+}
+
+// Call looks up an existing user by name or email.
+func (GetUser) Call(ctx p.Context, input GetUserArgs) (GetUserResult, error) {
+	result := GetUserResult{}
+
+	if (input.Name == nil) == (input.Email == nil) {
+		return result, errors.New("exactly one of name or email must be specified")
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	result.OrganizationId, err = resolveOrganizationId(ctx, workmailclient, input.OrganizationId, input.Domain)
+	if err != nil {
+		return result, err
+	}
+
+	// The list filters only match prefixes, so the exact match is done here.
+	filters := &types.ListUsersFilters{UsernamePrefix: input.Name, PrimaryEmailPrefix: input.Email}
+	users, err := listUsers(ctx, workmailclient, result.OrganizationId, filters)
+	if err != nil {
+		return result, err
+	}
+	user, found := Find(func(user types.User) bool {
+		if input.Name != nil {
+			return user.Name != nil && *user.Name == *input.Name
+		}
+		return user.Email != nil && *user.Email == *input.Email
+	})(users)
+	if !found {
+		return result, fmt.Errorf("no workmail user %s found in organization %s",
+			ifNotNil(input.Name, ifNotNil(input.Email, "")), result.OrganizationId)
+	}
+
+	details, err := workmailclient.DescribeUser(ctx, &workmail.DescribeUserInput{
+		OrganizationId: &result.OrganizationId,
+		UserId:         user.Id,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.UserId = ifNotNil(details.UserId, "")
+	result.Name = ifNotNil(details.Name, "")
+	result.Email = details.Email
+	result.DisplayName = details.DisplayName
+	result.FirstName = details.FirstName
+	result.LastName = details.LastName
+	result.HiddenFromGlobalAddressList = details.HiddenFromGlobalAddressList
+	result.State = string(details.State)
+	result.UserRole = string(details.UserRole)
+
+	return result, nil
+}
+
+// listUsers pages through all users of an organization that match the given filters.
+func listUsers(ctx p.Context, workmailclient *workmail.Client, organizationId string, filters *types.ListUsersFilters) ([]types.User, error) {
+	users := []types.User{}
+	paginator := workmail.NewListUsersPaginator(workmailclient, &workmail.ListUsersInput{
+		OrganizationId: &organizationId,
+		Filters:        filters,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, page.Users...)
+	}
+	return users, nil
+}
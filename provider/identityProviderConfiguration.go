@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type IdentityProviderConfiguration struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type IdentityProviderConfigurationArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the identity provider is configured for.
+	OrganizationId string `pulumi:"organizationId"`
+	// How users sign in to WorkMail.
+	AuthenticationMode AuthenticationMode `pulumi:"authenticationMode"`
+	// The ARN of the IAM Identity Center instance. Must be in the same account and region
+	// as the organization.
+	InstanceArn string `pulumi:"instanceArn"`
+	// The ARN of the IAM Identity Center application for WorkMail. When omitted, an
+	// application is created with the WorkMail API and deleted together with this resource.
+	ApplicationArn *string `pulumi:"applicationArn,optional"`
+	// Whether users can create personal access tokens to connect email clients.
+	PersonalAccessTokenStatus PersonalAccessTokenStatus `pulumi:"personalAccessTokenStatus"`
+	// The validity of personal access tokens in days.
+	PersonalAccessTokenLifetimeInDays *int `pulumi:"personalAccessTokenLifetimeInDays,optional"`
+}
+
+type AuthenticationMode string
+
+const (
+	AuthenticationModeIdentityProviderOnly         AuthenticationMode = "IDENTITY_PROVIDER_ONLY"
+	AuthenticationModeIdentityProviderAndDirectory AuthenticationMode = "IDENTITY_PROVIDER_AND_DIRECTORY"
+)
+
+func (AuthenticationMode) Values() []infer.EnumValue[AuthenticationMode] {
+	return []infer.EnumValue[AuthenticationMode]{
+		{Name: "IdentityProviderOnly", Value: AuthenticationModeIdentityProviderOnly, Description: "Users sign in with IAM Identity Center only."},
+		{Name: "IdentityProviderAndDirectory", Value: AuthenticationModeIdentityProviderAndDirectory, Description: "Users sign in with IAM Identity Center or their WorkMail directory password."},
+	}
+}
+
+type PersonalAccessTokenStatus string
+
+const (
+	PersonalAccessTokenStatusActive   PersonalAccessTokenStatus = "ACTIVE"
+	PersonalAccessTokenStatusInactive PersonalAccessTokenStatus = "INACTIVE"
+)
+
+func (PersonalAccessTokenStatus) Values() []infer.EnumValue[PersonalAccessTokenStatus] {
+	return []infer.EnumValue[PersonalAccessTokenStatus]{
+		{Name: "Active", Value: PersonalAccessTokenStatusActive, Description: "Users can create personal access tokens."},
+		{Name: "Inactive", Value: PersonalAccessTokenStatusInactive, Description: "Personal access tokens are disabled."},
+	}
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type IdentityProviderConfigurationState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	IdentityProviderConfigurationArgs
+
+	// The ARN of the IAM Identity Center application used by the configuration.
+	CurrentApplicationArn string `pulumi:"currentApplicationArn"`
+}
+
+// Check validates the inputs before they are used.
+func (IdentityProviderConfiguration) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (IdentityProviderConfigurationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args IdentityProviderConfigurationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("instanceArn", args.InstanceArn, validateInstanceArn)
+		c.checkOptional("applicationArn", args.ApplicationArn, validateApplicationArn)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (IdentityProviderConfiguration) Create(ctx p.Context, name string, input IdentityProviderConfigurationArgs, preview bool) (string, IdentityProviderConfigurationState, error) {
+	state := IdentityProviderConfigurationState{IdentityProviderConfigurationArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	if input.ApplicationArn != nil {
+		state.CurrentApplicationArn = *input.ApplicationArn
+	} else {
+		application, err := workmailclient.CreateIdentityCenterApplication(ctx, &workmail.CreateIdentityCenterApplicationInput{
+			Name:        &name,
+			InstanceArn: &input.InstanceArn,
+		})
+		if err != nil {
+			return "", state, err
+		}
+		state.CurrentApplicationArn = *application.ApplicationArn
+	}
+
+	err = putIdentityProviderConfiguration(ctx, workmailclient, state)
+	if err != nil && input.ApplicationArn == nil {
+		// Delete the application created above, so it is not leaked.
+		_, deleteErr := workmailclient.DeleteIdentityCenterApplication(ctx, &workmail.DeleteIdentityCenterApplicationInput{
+			ApplicationArn: &state.CurrentApplicationArn,
+		})
+		return "", state, errors.Join(err, deleteErr)
+	}
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.OrganizationId, state, nil
+}
+
+func putIdentityProviderConfiguration(ctx p.Context, workmailclient *workmail.Client, state IdentityProviderConfigurationState) error {
+	if state.AuthenticationMode == AuthenticationModeIdentityProviderOnly {
+		err := warnDirectoryOnlyUsers(ctx, workmailclient, state.OrganizationId)
+		if err != nil {
+			return err
+		}
+	}
+
+	var lifetime *int32
+	if state.PersonalAccessTokenLifetimeInDays != nil {
+		days := int32(*state.PersonalAccessTokenLifetimeInDays)
+		lifetime = &days
+	}
+	_, err := workmailclient.PutIdentityProviderConfiguration(ctx, &workmail.PutIdentityProviderConfigurationInput{
+		OrganizationId:     &state.OrganizationId,
+		AuthenticationMode: types.IdentityProviderAuthenticationMode(state.AuthenticationMode),
+		IdentityCenterConfiguration: &types.IdentityCenterConfiguration{
+			InstanceArn:    &state.InstanceArn,
+			ApplicationArn: &state.CurrentApplicationArn,
+		},
+		PersonalAccessTokenConfiguration: &types.PersonalAccessTokenConfiguration{
+			Status:         types.PersonalAccessTokenConfigurationStatus(state.PersonalAccessTokenStatus),
+			LifetimeInDays: lifetime,
+		},
+	})
+
+	return err
+}
+
+// warnDirectoryOnlyUsers warns about enabled users that are not yet mapped to an Identity
+// Center user. They can no longer sign in with their directory password and are only
+// mapped once they sign in through Identity Center for the first time.
+func warnDirectoryOnlyUsers(ctx p.Context, workmailclient *workmail.Client, organizationId string) error {
+	users, err := listUsers(ctx, workmailclient, organizationId, &types.ListUsersFilters{State: types.EntityStateEnabled})
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.UserRole == types.UserRoleUser && user.IdentityProviderUserId == nil {
+			ctx.Logf(diag.Warning, "user %s is not mapped to an IAM Identity Center user and can no longer sign in with a directory password", ifNotNil(user.Name, ""))
+		}
+	}
+	return nil
+}
+
+func (IdentityProviderConfiguration) Diff(ctx p.Context, id string, olds IdentityProviderConfigurationState, news IdentityProviderConfigurationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  InstanceArn
+	if olds.InstanceArn != news.InstanceArn {
+		diffs["instanceArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  ApplicationArn
+	if ptrDiff(olds.ApplicationArn, news.ApplicationArn) {
+		diffs["applicationArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  AuthenticationMode
+	if olds.AuthenticationMode != news.AuthenticationMode {
+		diffs["authenticationMode"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  PersonalAccessTokenStatus
+	if olds.PersonalAccessTokenStatus != news.PersonalAccessTokenStatus {
+		diffs["personalAccessTokenStatus"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  PersonalAccessTokenLifetimeInDays
+	if ptrDiff(olds.PersonalAccessTokenLifetimeInDays, news.PersonalAccessTokenLifetimeInDays) {
+		diffs["personalAccessTokenLifetimeInDays"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+func (IdentityProviderConfiguration) Update(ctx p.Context, id string, olds IdentityProviderConfigurationState, news IdentityProviderConfigurationArgs, preview bool) (IdentityProviderConfigurationState, error) {
+	state := IdentityProviderConfigurationState{IdentityProviderConfigurationArgs: news, CurrentApplicationArn: olds.CurrentApplicationArn}
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	err = putIdentityProviderConfiguration(ctx, workmailclient, state)
+
+	return state, err
+}
+
+func (IdentityProviderConfiguration) Read(ctx p.Context, id string, inputs IdentityProviderConfigurationArgs, state IdentityProviderConfigurationState) (string, IdentityProviderConfigurationArgs, IdentityProviderConfigurationState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	configuration, err := workmailclient.DescribeIdentityProviderConfiguration(ctx, &workmail.DescribeIdentityProviderConfigurationInput{
+		OrganizationId: &id,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		// The configuration has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.OrganizationId = id
+	state.AuthenticationMode = AuthenticationMode(configuration.AuthenticationMode)
+	if configuration.IdentityCenterConfiguration != nil {
+		state.InstanceArn = ifNotNil(configuration.IdentityCenterConfiguration.InstanceArn, "")
+		state.CurrentApplicationArn = ifNotNil(configuration.IdentityCenterConfiguration.ApplicationArn, "")
+	}
+	if configuration.PersonalAccessTokenConfiguration != nil {
+		state.PersonalAccessTokenStatus = PersonalAccessTokenStatus(configuration.PersonalAccessTokenConfiguration.Status)
+		if lifetime := configuration.PersonalAccessTokenConfiguration.LifetimeInDays; lifetime != nil && state.PersonalAccessTokenLifetimeInDays != nil {
+			days := int(*lifetime)
+			state.PersonalAccessTokenLifetimeInDays = &days
+		}
+	}
+
+	return id, state.IdentityProviderConfigurationArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (IdentityProviderConfiguration) Delete(ctx p.Context, id string, props IdentityProviderConfigurationState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteIdentityProviderConfiguration(ctx, &workmail.DeleteIdentityProviderConfigurationInput{
+		OrganizationId: &props.OrganizationId,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only delete applications created by this resource.
+	if props.ApplicationArn == nil {
+		_, err = workmailclient.DeleteIdentityCenterApplication(ctx, &workmail.DeleteIdentityCenterApplicationInput{
+			ApplicationArn: &props.CurrentApplicationArn,
+		})
+	}
+
+	return err
+}
@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type ImpersonationRole struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type ImpersonationRoleArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the impersonation role is created in.
+	OrganizationId string `pulumi:"organizationId"`
+	// The impersonation role name.
+	Name string `pulumi:"name"`
+	// The impersonation role description.
+	Description *string `pulumi:"description,optional"`
+	// The access the impersonation role grants to the mailboxes of the target users.
+	Type ImpersonationRoleType `pulumi:"type"`
+	// The rules of the impersonation role, evaluated in order.
+	Rules []ImpersonationRule `pulumi:"rules"`
+	// The idempotency token associated with the request.
+	ClientToken *string `pulumi:"clientToken,optional"`
+}
+
+type ImpersonationRule struct {
+	// The rule id. Defaults to the position of the rule, e.g. rule-0.
+	RuleId *string `pulumi:"ruleId,optional"`
+	// The rule name.
+	Name *string `pulumi:"name,optional"`
+	// The rule description.
+	Description *string `pulumi:"description,optional"`
+	// The effect of the rule when it matches.
+	Effect AccessControlEffect `pulumi:"effect"`
+	// The ids of the users the rule matches.
+	TargetUsers []string `pulumi:"targetUsers,optional"`
+	// The ids of the users the rule does not match.
+	NotTargetUsers []string `pulumi:"notTargetUsers,optional"`
+}
+
+type ImpersonationRoleType string
+
+const (
+	ImpersonationRoleTypeFullAccess ImpersonationRoleType = "FULL_ACCESS"
+	ImpersonationRoleTypeReadOnly   ImpersonationRoleType = "READ_ONLY"
+)
+
+func (ImpersonationRoleType) Values() []infer.EnumValue[ImpersonationRoleType] {
+	return []infer.EnumValue[ImpersonationRoleType]{
+		{Name: "FullAccess", Value: ImpersonationRoleTypeFullAccess, Description: "Full access to the mailboxes of the target users."},
+		{Name: "ReadOnly", Value: ImpersonationRoleTypeReadOnly, Description: "Read-only access to the mailboxes of the target users."},
+	}
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type ImpersonationRoleState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	ImpersonationRoleArgs
+
+	// The impersonation role id.
+	ImpersonationRoleId string `pulumi:"impersonationRoleId"`
+}
+
+// Check validates the inputs before they are used.
+func (ImpersonationRole) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (ImpersonationRoleArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args ImpersonationRoleArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (ImpersonationRole) Create(ctx p.Context, name string, input ImpersonationRoleArgs, preview bool) (string, ImpersonationRoleState, error) {
+	state := ImpersonationRoleState{ImpersonationRoleArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	err = ensureImpersonationTargetsExist(ctx, workmailclient, input)
+	if err != nil {
+		return "", state, err
+	}
+
+	role, err := workmailclient.CreateImpersonationRole(ctx, &workmail.CreateImpersonationRoleInput{
+		OrganizationId: &input.OrganizationId,
+		Name:           &input.Name,
+		Description:    input.Description,
+		Type:           types.ImpersonationRoleType(input.Type),
+		Rules:          toImpersonationRules(input.Rules),
+		ClientToken:    input.ClientToken,
+	})
+	if err != nil {
+		return "", state, err
+	}
+	state.ImpersonationRoleId = *role.ImpersonationRoleId
+
+	return state.ImpersonationRoleId, state, nil
+}
+
+func toImpersonationRules(rules []ImpersonationRule) []types.ImpersonationRule {
+	result := make([]types.ImpersonationRule, len(rules))
+	for i, rule := range rules {
+		ruleId := ifNotNil(rule.RuleId, fmt.Sprintf("rule-%d", i))
+		result[i] = types.ImpersonationRule{
+			ImpersonationRuleId: &ruleId,
+			Name:                rule.Name,
+			Description:         rule.Description,
+			Effect:              types.AccessEffect(rule.Effect),
+			TargetUsers:         rule.TargetUsers,
+			NotTargetUsers:      rule.NotTargetUsers,
+		}
+	}
+	return result
+}
+
+// ensureImpersonationTargetsExist ensures all users referenced by the rules exist.
+func ensureImpersonationTargetsExist(ctx p.Context, workmailclient *workmail.Client, input ImpersonationRoleArgs) error {
+	for _, rule := range input.Rules {
+		err := ensureUsersExist(ctx, workmailclient, input.OrganizationId, append(append([]string{}, rule.TargetUsers...), rule.NotTargetUsers...))
+		if err != nil {
+			return fmt.Errorf("impersonation role %s: %w", input.Name, err)
+		}
+	}
+	return nil
+}
+
+func (ImpersonationRole) Diff(ctx p.Context, id string, olds ImpersonationRoleState, news ImpersonationRoleArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Name
+	if olds.Name != news.Name {
+		diffs["name"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Description
+	if ptrDiff(olds.Description, news.Description) {
+		diffs["description"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Type
+	if olds.Type != news.Type {
+		diffs["type"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Rules
+	if impersonationRulesDiff(olds.Rules, news.Rules) {
+		diffs["rules"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+func impersonationRulesDiff(a, b []ImpersonationRule) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		if ptrDiff(a[i].RuleId, b[i].RuleId) ||
+			ptrDiff(a[i].Name, b[i].Name) ||
+			ptrDiff(a[i].Description, b[i].Description) ||
+			a[i].Effect != b[i].Effect ||
+			sliceDiff(a[i].TargetUsers, b[i].TargetUsers) ||
+			sliceDiff(a[i].NotTargetUsers, b[i].NotTargetUsers) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ImpersonationRole) Update(ctx p.Context, id string, olds ImpersonationRoleState, news ImpersonationRoleArgs, preview bool) (ImpersonationRoleState, error) {
+	state := ImpersonationRoleState{ImpersonationRoleArgs: news, ImpersonationRoleId: olds.ImpersonationRoleId}
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	err = ensureImpersonationTargetsExist(ctx, workmailclient, news)
+	if err != nil {
+		return state, err
+	}
+
+	_, err = workmailclient.UpdateImpersonationRole(ctx, &workmail.UpdateImpersonationRoleInput{
+		OrganizationId:      &news.OrganizationId,
+		ImpersonationRoleId: &state.ImpersonationRoleId,
+		Name:                &news.Name,
+		Description:         news.Description,
+		Type:                types.ImpersonationRoleType(news.Type),
+		Rules:               toImpersonationRules(news.Rules),
+	})
+
+	return state, err
+}
+
+func (ImpersonationRole) Read(ctx p.Context, id string, inputs ImpersonationRoleArgs, state ImpersonationRoleState) (string, ImpersonationRoleArgs, ImpersonationRoleState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	role, err := workmailclient.GetImpersonationRole(ctx, &workmail.GetImpersonationRoleInput{
+		OrganizationId:      &state.OrganizationId,
+		ImpersonationRoleId: &id,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		// The role has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.ImpersonationRoleId = id
+	state.Name = ifNotNil(role.Name, "")
+	state.Description = role.Description
+	state.Type = ImpersonationRoleType(role.Type)
+	rules := make([]ImpersonationRule, len(role.Rules))
+	for i, rule := range role.Rules {
+		rules[i] = ImpersonationRule{
+			RuleId:         rule.ImpersonationRuleId,
+			Name:           rule.Name,
+			Description:    rule.Description,
+			Effect:         AccessControlEffect(rule.Effect),
+			TargetUsers:    rule.TargetUsers,
+			NotTargetUsers: rule.NotTargetUsers,
+		}
+		// Keep generated rule ids implicit, so they don't show up as drift.
+		if i < len(state.Rules) && state.Rules[i].RuleId == nil && ifNotNil(rule.ImpersonationRuleId, "") == fmt.Sprintf("rule-%d", i) {
+			rules[i].RuleId = nil
+		}
+	}
+	state.Rules = rules
+
+	return id, state.ImpersonationRoleArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (ImpersonationRole) Delete(ctx p.Context, id string, props ImpersonationRoleState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteImpersonationRole(ctx, &workmail.DeleteImpersonationRoleInput{
+		OrganizationId:      &props.OrganizationId,
+		ImpersonationRoleId: &id,
+	})
+
+	return err
+}
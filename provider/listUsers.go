@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type ListUsers struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type ListUsersArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The identifier of the organization to list users of. Either organizationId or domain
+	// must be specified. When both are specified, organizationId is ignored.
+	OrganizationId *string `pulumi:"organizationId,optional"`
+	// The mail domain of the organization to list users of. Either organizationId or domain
+	// must be specified. When both are specified, organizationId is ignored.
+	Domain *string `pulumi:"domain,optional"`
+	// Filters only users with the provided username prefix.
+	UsernamePrefix *string `pulumi:"usernamePrefix,optional"`
+	// Filters only users with the provided display name prefix.
+	DisplayNamePrefix *string `pulumi:"displayNamePrefix,optional"`
+	// Filters only users with the provided email prefix.
+	PrimaryEmailPrefix *string `pulumi:"primaryEmailPrefix,optional"`
+	// Filters only users with the provided state (ENABLED, DISABLED or DELETED).
+	State *string `pulumi:"state,optional"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type ListUsersResult struct {
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The users matching the filters.
+	Users []UserSummary `pulumi:"users"`
+}
+
+type UserSummary struct {
+	UserId      string  `pulumi:"userId"`
+	Name        string  `pulumi:"name"`
+	Email       *string `pulumi:"email,optional"`
+	DisplayName *string `pulumi:"displayName,optional"`
+	State       string  `pulumi:"state"`
+	UserRole    string  `pulumi:"userRole"`
+}
+
+// Call lists the users of an organization.
+func (ListUsers) Call(ctx p.Context, input ListUsersArgs) (ListUsersResult, error) {
+	result := ListUsersResult{}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	result.OrganizationId, err = resolveOrganizationId(ctx, workmailclient, input.OrganizationId, input.Domain)
+	if err != nil {
+		return result, err
+	}
+
+	users, err := listUsers(ctx, workmailclient, result.OrganizationId, &types.ListUsersFilters{
+		UsernamePrefix:     input.UsernamePrefix,
+		DisplayNamePrefix:  input.DisplayNamePrefix,
+		PrimaryEmailPrefix: input.PrimaryEmailPrefix,
+		State:              types.EntityState(ifNotNil(input.State, "")),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Users = Map(func(user types.User) UserSummary {
+		return UserSummary{
+			UserId:      ifNotNil(user.Id, ""),
+			Name:        ifNotNil(user.Name, ""),
+			Email:       user.Email,
+			DisplayName: user.DisplayName,
+			State:       string(user.State),
+			UserRole:    string(user.UserRole),
+		}
+	})(users)
+
+	return result, nil
+}
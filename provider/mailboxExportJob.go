@@ -0,0 +1,253 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	"github.com/aws/smithy-go/middleware"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+//
+// A MailboxExportJob exports a mailbox to S3 and only completes once the export has
+// finished. To archive a mailbox before its user is deleted, add the export job in the
+// same update that removes the user: pulumi creates new resources before deleting removed
+// ones.
+// An export that is interrupted, e.g. by cancelling the update, is cancelled. Deleting the
+// export job never deletes the export.
+type MailboxExportJob struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type MailboxExportJobArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization of the exported mailbox.
+	OrganizationId string `pulumi:"organizationId"`
+	// The id, name or email address of the user or resource whose mailbox is exported.
+	EntityId string `pulumi:"entityId"`
+	// The S3 bucket the mailbox is exported to.
+	S3BucketName string `pulumi:"s3BucketName"`
+	// The S3 prefix the mailbox is exported to.
+	S3Prefix string `pulumi:"s3Prefix"`
+	// The ARN of the symmetric KMS key that encrypts the exported mailbox.
+	KmsKeyArn string `pulumi:"kmsKeyArn"`
+	// The ARN of the IAM role that grants write permission to the S3 bucket.
+	RoleArn string `pulumi:"roleArn"`
+	// The export job description.
+	Description *string `pulumi:"description,optional"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type MailboxExportJobState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	MailboxExportJobArgs
+
+	// The export job id.
+	JobId string `pulumi:"jobId"`
+	// The state of the export job, one of RUNNING, COMPLETED, FAILED or CANCELLED.
+	State string `pulumi:"state"`
+	// The estimated progress of the export job in percent.
+	EstimatedProgress int `pulumi:"estimatedProgress"`
+	// The S3 path of the exported mailbox.
+	S3Path *string `pulumi:"s3Path,optional"`
+	// The size of the exported mailbox in megabytes, estimated from the mailbox size when
+	// the export started.
+	EstimatedSizeMb *float64 `pulumi:"estimatedSizeMb,optional"`
+}
+
+// Check validates the inputs before they are used.
+func (MailboxExportJob) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (MailboxExportJobArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args MailboxExportJobArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("kmsKeyArn", args.KmsKeyArn, validateKmsKeyArn)
+		c.check("roleArn", args.RoleArn, validateRoleArn)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (MailboxExportJob) Create(ctx p.Context, name string, input MailboxExportJobArgs, preview bool) (string, MailboxExportJobState, error) {
+	state := MailboxExportJobState{MailboxExportJobArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	mailbox, err := workmailclient.GetMailboxDetails(ctx, &workmail.GetMailboxDetailsInput{
+		OrganizationId: &input.OrganizationId,
+		UserId:         &input.EntityId,
+	})
+	if err == nil {
+		state.EstimatedSizeMb = &mailbox.MailboxSize
+	}
+
+	clientToken := name + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	job, err := workmailclient.StartMailboxExportJob(ctx, &workmail.StartMailboxExportJobInput{
+		ClientToken:    &clientToken,
+		OrganizationId: &input.OrganizationId,
+		EntityId:       &input.EntityId,
+		S3BucketName:   &input.S3BucketName,
+		S3Prefix:       &input.S3Prefix,
+		KmsKeyArn:      &input.KmsKeyArn,
+		RoleArn:        &input.RoleArn,
+		Description:    input.Description,
+	})
+	if err != nil {
+		return "", state, err
+	}
+	state.JobId = *job.JobId
+
+	// Wait for the export to finish
+	var metadata middleware.Metadata
+	for {
+		metadata, err = readMailboxExportJob(ctx, workmailclient, &state)
+		if err == nil && state.State != string(types.MailboxExportJobStateRunning) {
+			break
+		}
+		// Requests fail once the update is interrupted, which cancels the job below.
+		if err != nil && ctx.Err() == nil {
+			// The job would not be tracked by pulumi, so it must not keep running.
+			return "", state, errors.Join(err, cancelMailboxExportJob(ctx, workmailclient, state))
+		}
+		ctx.LogStatusf(diag.Info, "exporting mailbox of %s: %d%%", input.EntityId, state.EstimatedProgress)
+		select {
+		case <-ctx.Done():
+			// The job would not be tracked by pulumi, so it must not keep running.
+			return "", state, errors.Join(ctx.Err(), cancelMailboxExportJob(context.WithoutCancel(ctx), workmailclient, state))
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	if state.State != string(types.MailboxExportJobStateCompleted) {
+		return "", state, requestFailed(metadata, "mailbox export job %s %s", state.JobId, state.State)
+	}
+	ctx.LogStatusf(diag.Info, "exported mailbox of %s to %s", input.EntityId, ifNotNil(state.S3Path, input.S3BucketName))
+
+	return state.JobId, state, nil
+}
+
+// cancelMailboxExportJob stops a running export job.
+func cancelMailboxExportJob(ctx context.Context, workmailclient *workmail.Client, state MailboxExportJobState) error {
+	clientToken := state.JobId + "-cancel"
+	_, err := workmailclient.CancelMailboxExportJob(ctx, &workmail.CancelMailboxExportJobInput{
+		OrganizationId: &state.OrganizationId,
+		JobId:          &state.JobId,
+		ClientToken:    &clientToken,
+	})
+	return err
+}
+
+// readMailboxExportJob refreshes the state and progress of the export job and returns the
+// metadata of the response.
+func readMailboxExportJob(ctx p.Context, workmailclient *workmail.Client, state *MailboxExportJobState) (middleware.Metadata, error) {
+	job, err := workmailclient.DescribeMailboxExportJob(ctx, &workmail.DescribeMailboxExportJobInput{
+		OrganizationId: &state.OrganizationId,
+		JobId:          &state.JobId,
+	})
+	if err != nil {
+		return middleware.Metadata{}, err
+	}
+
+	state.State = string(job.State)
+	state.EstimatedProgress = int(job.EstimatedProgress)
+	state.S3Path = job.S3Path
+	if job.ErrorInfo != nil {
+		ctx.Logf(diag.Error, "mailbox export job %s: %s", state.JobId, *job.ErrorInfo)
+	}
+	return job.ResultMetadata, nil
+}
+
+// Every input change starts a new export job.
+func (MailboxExportJob) Diff(ctx p.Context, id string, olds MailboxExportJobState, news MailboxExportJobArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  EntityId
+	if olds.EntityId != news.EntityId {
+		diffs["entityId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  S3BucketName
+	if olds.S3BucketName != news.S3BucketName {
+		diffs["s3BucketName"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  S3Prefix
+	if olds.S3Prefix != news.S3Prefix {
+		diffs["s3Prefix"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  KmsKeyArn
+	if olds.KmsKeyArn != news.KmsKeyArn {
+		diffs["kmsKeyArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  RoleArn
+	if olds.RoleArn != news.RoleArn {
+		diffs["roleArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Description
+	if ptrDiff(olds.Description, news.Description) {
+		diffs["description"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+func (MailboxExportJob) Read(ctx p.Context, id string, inputs MailboxExportJobArgs, state MailboxExportJobState) (string, MailboxExportJobArgs, MailboxExportJobState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.JobId = id
+	_, err = readMailboxExportJob(ctx, workmailclient, &state)
+	var notFound *types.EntityNotFoundException
+	if errors.As(err, &notFound) {
+		// The job has expired. Only completed jobs are stored and their export stays in S3.
+		return id, inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	return id, inputs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (MailboxExportJob) Delete(ctx p.Context, id string, props MailboxExportJobState) error {
+	// Export jobs are only created once completed and cannot be deleted. The export stays
+	// in S3.
+	return nil
+}
@@ -0,0 +1,205 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type MailboxPermission struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type MailboxPermissionArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the mailbox belongs to.
+	OrganizationId string `pulumi:"organizationId"`
+	// The id of the user, group or resource whose mailbox permissions are granted.
+	EntityId string `pulumi:"entityId"`
+	// The id of the user or group that is granted the permissions.
+	GranteeId string `pulumi:"granteeId"`
+	// The permissions granted to the grantee.
+	Permissions []MailboxPermissionType `pulumi:"permissions"`
+}
+
+type MailboxPermissionType string
+
+const (
+	MailboxPermissionTypeFullAccess   MailboxPermissionType = "FULL_ACCESS"
+	MailboxPermissionTypeSendAs       MailboxPermissionType = "SEND_AS"
+	MailboxPermissionTypeSendOnBehalf MailboxPermissionType = "SEND_ON_BEHALF"
+)
+
+func (MailboxPermissionType) Values() []infer.EnumValue[MailboxPermissionType] {
+	return []infer.EnumValue[MailboxPermissionType]{
+		{Name: "FullAccess", Value: MailboxPermissionTypeFullAccess, Description: "Full access to the mailbox."},
+		{Name: "SendAs", Value: MailboxPermissionTypeSendAs, Description: "Send email as the mailbox owner."},
+		{Name: "SendOnBehalf", Value: MailboxPermissionTypeSendOnBehalf, Description: "Send email on behalf of the mailbox owner."},
+	}
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type MailboxPermissionState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	MailboxPermissionArgs
+}
+
+// Check validates the inputs before they are used.
+func (MailboxPermission) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (MailboxPermissionArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args MailboxPermissionArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (MailboxPermission) Create(ctx p.Context, name string, input MailboxPermissionArgs, preview bool) (string, MailboxPermissionState, error) {
+	state := MailboxPermissionState{MailboxPermissionArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	err := putMailboxPermissions(ctx, input)
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.EntityId + "/" + input.GranteeId, state, nil
+}
+
+func (MailboxPermission) Diff(ctx p.Context, id string, olds MailboxPermissionState, news MailboxPermissionArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  EntityId
+	if olds.EntityId != news.EntityId {
+		diffs["entityId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  GranteeId
+	if olds.GranteeId != news.GranteeId {
+		diffs["granteeId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Permissions
+	if setDiff(olds.Permissions, news.Permissions) {
+		diffs["permissions"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	// Permissions for an entity and grantee are unique, so the old ones have to go first.
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+// setDiff reports whether a and b contain different elements, ignoring order.
+func setDiff[T comparable](a, b []T) bool {
+	set := make(map[T]bool, len(a))
+	for _, element := range a {
+		set[element] = true
+	}
+	for _, element := range b {
+		if !set[element] {
+			return true
+		}
+		delete(set, element)
+	}
+	return len(set) > 0
+}
+
+func (MailboxPermission) Update(ctx p.Context, id string, olds MailboxPermissionState, news MailboxPermissionArgs, preview bool) (MailboxPermissionState, error) {
+	state := MailboxPermissionState{MailboxPermissionArgs: news}
+	if preview {
+		return state, nil
+	}
+
+	// PutMailboxPermissions replaces the permissions of the grantee.
+	err := putMailboxPermissions(ctx, news)
+
+	return state, err
+}
+
+func putMailboxPermissions(ctx p.Context, input MailboxPermissionArgs) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.PutMailboxPermissions(ctx, &workmail.PutMailboxPermissionsInput{
+		OrganizationId: &input.OrganizationId,
+		EntityId:       &input.EntityId,
+		GranteeId:      &input.GranteeId,
+		PermissionValues: Map(func(permission MailboxPermissionType) types.PermissionType {
+			return types.PermissionType(permission)
+		})(input.Permissions),
+	})
+
+	return err
+}
+
+func (MailboxPermission) Read(ctx p.Context, id string, inputs MailboxPermissionArgs, state MailboxPermissionState) (string, MailboxPermissionArgs, MailboxPermissionState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	paginator := workmail.NewListMailboxPermissionsPaginator(workmailclient, &workmail.ListMailboxPermissionsInput{
+		OrganizationId: &state.OrganizationId,
+		EntityId:       &state.EntityId,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", inputs, state, err
+		}
+		permission, found := Find(func(permission types.Permission) bool {
+			return permission.GranteeId != nil && *permission.GranteeId == state.GranteeId
+		})(page.Permissions)
+		if found {
+			state.Permissions = Map(func(permission types.PermissionType) MailboxPermissionType {
+				return MailboxPermissionType(permission)
+			})(permission.PermissionValues)
+			return id, state.MailboxPermissionArgs, state, nil
+		}
+	}
+
+	// The permissions have been revoked outside of pulumi.
+	return "", inputs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (MailboxPermission) Delete(ctx p.Context, id string, props MailboxPermissionState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteMailboxPermissions(ctx, &workmail.DeleteMailboxPermissionsInput{
+		OrganizationId: &props.OrganizationId,
+		EntityId:       &props.EntityId,
+		GranteeId:      &props.GranteeId,
+	})
+
+	return err
+}
@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type MobileDeviceAccessOverride struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type MobileDeviceAccessOverrideArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the override is created in.
+	OrganizationId string `pulumi:"organizationId"`
+	// The user the override applies to. Accepts the user id, username or email address.
+	UserId string `pulumi:"userId"`
+	// The mobile device the override applies to.
+	DeviceId string `pulumi:"deviceId"`
+	// The effect of the override.
+	Effect MobileDeviceAccessEffect `pulumi:"effect"`
+	// The override description.
+	Description *string `pulumi:"description,optional"`
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type MobileDeviceAccessOverrideState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	MobileDeviceAccessOverrideArgs
+}
+
+// Check validates the inputs before they are used.
+func (MobileDeviceAccessOverride) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (MobileDeviceAccessOverrideArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args MobileDeviceAccessOverrideArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (MobileDeviceAccessOverride) Create(ctx p.Context, name string, input MobileDeviceAccessOverrideArgs, preview bool) (string, MobileDeviceAccessOverrideState, error) {
+	state := MobileDeviceAccessOverrideState{MobileDeviceAccessOverrideArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	err := putMobileDeviceAccessOverride(ctx, input)
+	if err != nil {
+		return "", state, err
+	}
+
+	return input.UserId + "/" + input.DeviceId, state, nil
+}
+
+func (MobileDeviceAccessOverride) Diff(ctx p.Context, id string, olds MobileDeviceAccessOverrideState, news MobileDeviceAccessOverrideArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  UserId
+	if olds.UserId != news.UserId {
+		diffs["userId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  DeviceId
+	if olds.DeviceId != news.DeviceId {
+		diffs["deviceId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Effect
+	if olds.Effect != news.Effect {
+		diffs["effect"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Description
+	if ptrDiff(olds.Description, news.Description) {
+		diffs["description"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	// The override for a user and device is unique, so the old one has to go first.
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+func (MobileDeviceAccessOverride) Update(ctx p.Context, id string, olds MobileDeviceAccessOverrideState, news MobileDeviceAccessOverrideArgs, preview bool) (MobileDeviceAccessOverrideState, error) {
+	state := MobileDeviceAccessOverrideState{MobileDeviceAccessOverrideArgs: news}
+	if preview {
+		return state, nil
+	}
+
+	// PutMobileDeviceAccessOverride overwrites an existing override.
+	err := putMobileDeviceAccessOverride(ctx, news)
+
+	return state, err
+}
+
+func putMobileDeviceAccessOverride(ctx p.Context, input MobileDeviceAccessOverrideArgs) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.PutMobileDeviceAccessOverride(ctx, &workmail.PutMobileDeviceAccessOverrideInput{
+		OrganizationId: &input.OrganizationId,
+		UserId:         &input.UserId,
+		DeviceId:       &input.DeviceId,
+		Effect:         types.MobileDeviceAccessRuleEffect(input.Effect),
+		Description:    input.Description,
+	})
+
+	return err
+}
+
+func (MobileDeviceAccessOverride) Read(ctx p.Context, id string, inputs MobileDeviceAccessOverrideArgs, state MobileDeviceAccessOverrideState) (string, MobileDeviceAccessOverrideArgs, MobileDeviceAccessOverrideState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	override, err := workmailclient.GetMobileDeviceAccessOverride(ctx, &workmail.GetMobileDeviceAccessOverrideInput{
+		OrganizationId: &state.OrganizationId,
+		UserId:         &state.UserId,
+		DeviceId:       &state.DeviceId,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		// The override has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.Effect = MobileDeviceAccessEffect(override.Effect)
+	state.Description = override.Description
+
+	return id, state.MobileDeviceAccessOverrideArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (MobileDeviceAccessOverride) Delete(ctx p.Context, id string, props MobileDeviceAccessOverrideState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteMobileDeviceAccessOverride(ctx, &workmail.DeleteMobileDeviceAccessOverrideInput{
+		OrganizationId: &props.OrganizationId,
+		UserId:         &props.UserId,
+		DeviceId:       &props.DeviceId,
+	})
+
+	return err
+}
@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type MobileDeviceAccessRule struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type MobileDeviceAccessRuleArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the rule is created in.
+	OrganizationId string `pulumi:"organizationId"`
+	// The rule name.
+	Name string `pulumi:"name"`
+	// The rule description.
+	Description *string `pulumi:"description,optional"`
+	// The effect of the rule when it matches.
+	Effect MobileDeviceAccessEffect `pulumi:"effect"`
+	// Device types that the rule will match.
+	DeviceTypes []string `pulumi:"deviceTypes,optional"`
+	// Device models that the rule will match.
+	DeviceModels []string `pulumi:"deviceModels,optional"`
+	// Device operating systems that the rule will match.
+	DeviceOperatingSystems []string `pulumi:"deviceOperatingSystems,optional"`
+	// Device user agents that the rule will match.
+	DeviceUserAgents []string `pulumi:"deviceUserAgents,optional"`
+	// Device types that the rule will not match. All other device types will match.
+	NotDeviceTypes []string `pulumi:"notDeviceTypes,optional"`
+	// Device models that the rule will not match. All other device models will match.
+	NotDeviceModels []string `pulumi:"notDeviceModels,optional"`
+	// Device operating systems that the rule will not match. All other device operating
+	// systems will match.
+	NotDeviceOperatingSystems []string `pulumi:"notDeviceOperatingSystems,optional"`
+	// Device user agents that the rule will not match. All other device user agents will match.
+	NotDeviceUserAgents []string `pulumi:"notDeviceUserAgents,optional"`
+}
+
+type MobileDeviceAccessEffect string
+
+const (
+	MobileDeviceAccessEffectAllow MobileDeviceAccessEffect = "ALLOW"
+	MobileDeviceAccessEffectDeny  MobileDeviceAccessEffect = "DENY"
+)
+
+func (MobileDeviceAccessEffect) Values() []infer.EnumValue[MobileDeviceAccessEffect] {
+	return []infer.EnumValue[MobileDeviceAccessEffect]{
+		{Name: "Allow", Value: MobileDeviceAccessEffectAllow, Description: "Allow access for matching devices."},
+		{Name: "Deny", Value: MobileDeviceAccessEffectDeny, Description: "Deny access for matching devices."},
+	}
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type MobileDeviceAccessRuleState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	MobileDeviceAccessRuleArgs
+
+	// The mobile device access rule id.
+	MobileDeviceAccessRuleId string `pulumi:"mobileDeviceAccessRuleId"`
+}
+
+// Check validates the inputs before they are used.
+func (MobileDeviceAccessRule) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (MobileDeviceAccessRuleArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args MobileDeviceAccessRuleArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (MobileDeviceAccessRule) Create(ctx p.Context, name string, input MobileDeviceAccessRuleArgs, preview bool) (string, MobileDeviceAccessRuleState, error) {
+	state := MobileDeviceAccessRuleState{MobileDeviceAccessRuleArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	rule, err := workmailclient.CreateMobileDeviceAccessRule(ctx, &workmail.CreateMobileDeviceAccessRuleInput{
+		OrganizationId:            &input.OrganizationId,
+		Name:                      &input.Name,
+		Description:               input.Description,
+		Effect:                    types.MobileDeviceAccessRuleEffect(input.Effect),
+		DeviceTypes:               input.DeviceTypes,
+		DeviceModels:              input.DeviceModels,
+		DeviceOperatingSystems:    input.DeviceOperatingSystems,
+		DeviceUserAgents:          input.DeviceUserAgents,
+		NotDeviceTypes:            input.NotDeviceTypes,
+		NotDeviceModels:           input.NotDeviceModels,
+		NotDeviceOperatingSystems: input.NotDeviceOperatingSystems,
+		NotDeviceUserAgents:       input.NotDeviceUserAgents,
+	})
+	if err != nil {
+		return "", state, err
+	}
+	state.MobileDeviceAccessRuleId = *rule.MobileDeviceAccessRuleId
+
+	return state.MobileDeviceAccessRuleId, state, nil
+}
+
+func (MobileDeviceAccessRule) Diff(ctx p.Context, id string, olds MobileDeviceAccessRuleState, news MobileDeviceAccessRuleArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Name
+	if olds.Name != news.Name {
+		diffs["name"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Description
+	if ptrDiff(olds.Description, news.Description) {
+		diffs["description"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Effect
+	if olds.Effect != news.Effect {
+		diffs["effect"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Device matchers
+	for key, values := range map[string][2][]string{
+		"deviceTypes":               {olds.DeviceTypes, news.DeviceTypes},
+		"deviceModels":              {olds.DeviceModels, news.DeviceModels},
+		"deviceOperatingSystems":    {olds.DeviceOperatingSystems, news.DeviceOperatingSystems},
+		"deviceUserAgents":          {olds.DeviceUserAgents, news.DeviceUserAgents},
+		"notDeviceTypes":            {olds.NotDeviceTypes, news.NotDeviceTypes},
+		"notDeviceModels":           {olds.NotDeviceModels, news.NotDeviceModels},
+		"notDeviceOperatingSystems": {olds.NotDeviceOperatingSystems, news.NotDeviceOperatingSystems},
+		"notDeviceUserAgents":       {olds.NotDeviceUserAgents, news.NotDeviceUserAgents},
+	} {
+		if sliceDiff(values[0], values[1]) {
+			diffs[key] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+		}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+func sliceDiff[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func (MobileDeviceAccessRule) Update(ctx p.Context, id string, olds MobileDeviceAccessRuleState, news MobileDeviceAccessRuleArgs, preview bool) (MobileDeviceAccessRuleState, error) {
+	state := MobileDeviceAccessRuleState{MobileDeviceAccessRuleArgs: news, MobileDeviceAccessRuleId: olds.MobileDeviceAccessRuleId}
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	_, err = workmailclient.UpdateMobileDeviceAccessRule(ctx, &workmail.UpdateMobileDeviceAccessRuleInput{
+		OrganizationId:            &news.OrganizationId,
+		MobileDeviceAccessRuleId:  &state.MobileDeviceAccessRuleId,
+		Name:                      &news.Name,
+		Description:               news.Description,
+		Effect:                    types.MobileDeviceAccessRuleEffect(news.Effect),
+		DeviceTypes:               news.DeviceTypes,
+		DeviceModels:              news.DeviceModels,
+		DeviceOperatingSystems:    news.DeviceOperatingSystems,
+		DeviceUserAgents:          news.DeviceUserAgents,
+		NotDeviceTypes:            news.NotDeviceTypes,
+		NotDeviceModels:           news.NotDeviceModels,
+		NotDeviceOperatingSystems: news.NotDeviceOperatingSystems,
+		NotDeviceUserAgents:       news.NotDeviceUserAgents,
+	})
+
+	return state, err
+}
+
+func (MobileDeviceAccessRule) Read(ctx p.Context, id string, inputs MobileDeviceAccessRuleArgs, state MobileDeviceAccessRuleState) (string, MobileDeviceAccessRuleArgs, MobileDeviceAccessRuleState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	rules, err := workmailclient.ListMobileDeviceAccessRules(ctx, &workmail.ListMobileDeviceAccessRulesInput{
+		OrganizationId: &state.OrganizationId,
+	})
+	if err != nil {
+		return "", inputs, state, err
+	}
+	rule, found := Find(func(rule types.MobileDeviceAccessRule) bool {
+		return rule.MobileDeviceAccessRuleId != nil && *rule.MobileDeviceAccessRuleId == id
+	})(rules.Rules)
+	if !found {
+		// The rule has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+
+	state.MobileDeviceAccessRuleId = id
+	state.Name = ifNotNil(rule.Name, "")
+	state.Description = rule.Description
+	state.Effect = MobileDeviceAccessEffect(rule.Effect)
+	state.DeviceTypes = rule.DeviceTypes
+	state.DeviceModels = rule.DeviceModels
+	state.DeviceOperatingSystems = rule.DeviceOperatingSystems
+	state.DeviceUserAgents = rule.DeviceUserAgents
+	state.NotDeviceTypes = rule.NotDeviceTypes
+	state.NotDeviceModels = rule.NotDeviceModels
+	state.NotDeviceOperatingSystems = rule.NotDeviceOperatingSystems
+	state.NotDeviceUserAgents = rule.NotDeviceUserAgents
+
+	return id, state.MobileDeviceAccessRuleArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (MobileDeviceAccessRule) Delete(ctx p.Context, id string, props MobileDeviceAccessRuleState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteMobileDeviceAccessRule(ctx, &workmail.DeleteMobileDeviceAccessRuleInput{
+		OrganizationId:           &props.OrganizationId,
+		MobileDeviceAccessRuleId: &id,
+	})
+
+	return err
+}
@@ -1,12 +1,16 @@
 package provider
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/workmail"
 	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
 // Each resource has a controlling struct.
@@ -23,8 +27,8 @@ type Organization struct{}
 
 // Each resource has an input struct, defining what arguments it accepts.
 type OrganizationArgs struct {
-	// The AWS Region. TODO: This should be passed as a pulumi.Provider
-	Region string `pulumi:"region"`
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
 	// The organization alias.
 	Alias string `pulumi:"alias"`
 	// The idempotency token associated with the request.
@@ -46,6 +50,15 @@ type OrganizationState struct {
 	OrganizationId string `pulumi:"organizationId"`
 }
 
+// Check validates the inputs before they are used.
+func (Organization) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (OrganizationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args OrganizationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("alias", args.Alias, validateAlias)
+		c.checkOptional("kmsKeyArn", args.KmsKeyArn, validateKmsKeyArn)
+	})
+}
+
 // All resources must implement Create at a minimum.
 func (Organization) Create(ctx p.Context, name string, input OrganizationArgs, preview bool) (string, OrganizationState, error) {
 	state := OrganizationState{OrganizationArgs: input}
@@ -53,14 +66,11 @@ func (Organization) Create(ctx p.Context, name string, input OrganizationArgs, p
 		return name, state, nil
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
 	if err != nil {
 		return "", state, err
 	}
-	cfg.Region = input.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	// Create the organization
 	organization, err := workmailclient.CreateOrganization(ctx, &workmail.CreateOrganizationInput{
@@ -76,22 +86,96 @@ func (Organization) Create(ctx p.Context, name string, input OrganizationArgs, p
 	}
 	state.OrganizationId = *organization.OrganizationId
 
-	// Wait for the organization to be created
+	// The organization exists from now on, so it is kept in the state when it fails to
+	// become active. It can then be deleted.
+	err = waitForOrganization(ctx, workmailclient, state)
+	if err != nil {
+		return state.OrganizationId, state, infer.ResourceInitFailedError{Reasons: []string{err.Error()}}
+	}
+
+	return state.OrganizationId, state, nil
+}
+
+// waitForOrganization waits for the organization to be created.
+func waitForOrganization(ctx p.Context, workmailclient *workmail.Client, state OrganizationState) error {
 	for {
 		org, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
 			OrganizationId: &state.OrganizationId,
 		})
 		if err != nil {
-			return "", state, err
+			return err
 		}
 
 		if *org.State == "Active" {
 			break
 		}
+		if org.ErrorMessage != nil {
+			return requestFailed(org.ResultMetadata, "organization %s is %s: %s", state.OrganizationId, *org.State, *org.ErrorMessage)
+		}
+		ctx.LogStatusf(diag.Info, "waiting for organization %s to become active: %s", state.Alias, *org.State)
 		time.Sleep(5 * time.Second)
 	}
+	ctx.LogStatusf(diag.Info, "organization %s is active", state.Alias)
+	return nil
+}
 
-	return state.OrganizationId, state, nil
+// Every input change replaces the organization.
+func (Organization) Diff(ctx p.Context, id string, olds OrganizationState, news OrganizationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Alias
+	if olds.Alias != news.Alias {
+		diffs["alias"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  ClientToken
+	if ptrDiff(olds.ClientToken, news.ClientToken) {
+		diffs["clientToken"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  DirectoryId
+	if ptrDiff(olds.DirectoryId, news.DirectoryId) {
+		diffs["directoryId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  KmsKeyArn
+	if ptrDiff(olds.KmsKeyArn, news.KmsKeyArn) {
+		diffs["kmsKeyArn"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  EnableInteroperability
+	if ptrDiff(olds.EnableInteroperability, news.EnableInteroperability) {
+		diffs["enableInteroperability"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+// Update is only called for an organization that failed to become active, since every
+// input change replaces the organization. It waits for the organization again.
+func (Organization) Update(ctx p.Context, id string, olds OrganizationState, news OrganizationArgs, preview bool) (OrganizationState, error) {
+	state := OrganizationState{OrganizationArgs: news, OrganizationId: olds.OrganizationId}
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	err = waitForOrganization(ctx, workmailclient, state)
+	if err != nil {
+		return state, infer.ResourceInitFailedError{Reasons: []string{err.Error()}}
+	}
+
+	return state, nil
 }
 
 func ifNotNil[T any](ptr *T, def T) T {
@@ -103,14 +187,11 @@ func ifNotNil[T any](ptr *T, def T) T {
 
 // The Delete method will run when the resource is deleted.
 func (Organization) Delete(ctx p.Context, id string, props OrganizationState) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
 	if err != nil {
 		return err
 	}
-	cfg.Region = props.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	organization, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
 		OrganizationId: &id,
@@ -140,6 +221,7 @@ func (Organization) Delete(ctx p.Context, id string, props OrganizationState) er
 			if *organization.State == "Deleted" {
 				break
 			}
+			ctx.LogStatusf(diag.Info, "waiting for organization %s to be deleted: %s", props.Alias, *organization.State)
 			time.Sleep(5 * time.Second)
 		}
 	}
@@ -156,3 +238,42 @@ func Map[T any, U any](f func(T) U) func([]T) []U {
 		return newSlice
 	}
 }
+
+// resolveOrganizationId returns the organization id for the given organizationId or mail
+// domain. Either organizationId or domain must be specified. When both are specified,
+// organizationId is ignored.
+func resolveOrganizationId(ctx p.Context, workmailclient *workmail.Client, organizationId *string, domain *string) (string, error) {
+	if organizationId == nil && domain == nil {
+		return "", errors.New("either organizationId or domain must be specified")
+	}
+	if domain == nil {
+		return *organizationId, nil
+	}
+
+	organization, found, err := findOrganization(ctx, workmailclient, func(org types.OrganizationSummary) bool {
+		return org.DefaultMailDomain != nil && *org.DefaultMailDomain == *domain
+	})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("no workmail organization with domain %s found", *domain)
+	}
+	return *organization.OrganizationId, nil
+}
+
+// findOrganization pages through all organizations of the account and returns the first
+// one that satisfies the predicate function.
+func findOrganization(ctx p.Context, workmailclient *workmail.Client, predicate func(types.OrganizationSummary) bool) (types.OrganizationSummary, bool, error) {
+	paginator := workmail.NewListOrganizationsPaginator(workmailclient, &workmail.ListOrganizationsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return types.OrganizationSummary{}, false, err
+		}
+		if organization, found := Find(predicate)(page.OrganizationSummaries); found {
+			return organization, true, nil
+		}
+	}
+	return types.OrganizationSummary{}, false, nil
+}
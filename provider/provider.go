@@ -23,6 +23,8 @@ import (
 	"github.com/pulumi/pulumi-go-provider/infer"
 	"github.com/pulumi/pulumi-go-provider/middleware/schema"
 	gen "github.com/pulumi/pulumi/pkg/v3/codegen/go"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 )
 
@@ -34,7 +36,8 @@ const Name string = "awsworkmail"
 func Provider() p.Provider {
 	// We tell the provider what resources it needs to support.
 	// In this case, a single custom resource.
-	return infer.Provider(infer.Options{
+	return withRequestMetrics(infer.Provider(infer.Options{
+		Config: infer.Config[*Config](),
 		Resources: []infer.InferredResource{
 			infer.Resource[Organization, OrganizationArgs, OrganizationState](),
 			infer.Resource[DefaultDomain, DefaultDomainArgs, DefaultDomainState](),
@@ -42,6 +45,29 @@ func Provider() p.Provider {
 			infer.Resource[WorkmailRegistration, WorkmailRegistrationArgs, WorkmailRegistrationState](),
 			infer.Resource[Random, RandomArgs, RandomState](),
 			infer.Resource[CognitoEmailSender, CognitoEmailSenderArgs, CognitoEmailSenderState](),
+			infer.Resource[CognitoSmsSender, CognitoSmsSenderArgs, CognitoSmsSenderState](),
+			infer.Resource[CognitoWorkmailEmailConfiguration, CognitoWorkmailEmailConfigurationArgs, CognitoWorkmailEmailConfigurationState](),
+			infer.Resource[MobileDeviceAccessRule, MobileDeviceAccessRuleArgs, MobileDeviceAccessRuleState](),
+			infer.Resource[MobileDeviceAccessOverride, MobileDeviceAccessOverrideArgs, MobileDeviceAccessOverrideState](),
+			infer.Resource[AccessControlRule, AccessControlRuleArgs, AccessControlRuleState](),
+			infer.Resource[MailboxPermission, MailboxPermissionArgs, MailboxPermissionState](),
+			infer.Resource[RetentionPolicy, RetentionPolicyArgs, RetentionPolicyState](),
+			infer.Resource[EmailMonitoringConfiguration, EmailMonitoringConfigurationArgs, EmailMonitoringConfigurationState](),
+			infer.Resource[AuditLogConfiguration, AuditLogConfigurationArgs, AuditLogConfigurationState](),
+			infer.Resource[AvailabilityConfiguration, AvailabilityConfigurationArgs, AvailabilityConfigurationState](),
+			infer.Resource[ImpersonationRole, ImpersonationRoleArgs, ImpersonationRoleState](),
+			infer.Resource[IdentityProviderConfiguration, IdentityProviderConfigurationArgs, IdentityProviderConfigurationState](),
+			infer.Resource[MailboxExportJob, MailboxExportJobArgs, MailboxExportJobState](),
+		},
+		Functions: []infer.InferredFunction{
+			infer.Function[GetOrganization, GetOrganizationArgs, GetOrganizationResult](),
+			infer.Function[GetUser, GetUserArgs, GetUserResult](),
+			infer.Function[GetGroup, GetGroupArgs, GetGroupResult](),
+			infer.Function[GetMailDomain, GetMailDomainArgs, GetMailDomainResult](),
+			infer.Function[ListUsers, ListUsersArgs, ListUsersResult](),
+			infer.Function[GetAccessControlEffect, GetAccessControlEffectArgs, GetAccessControlEffectResult](),
+			infer.Function[TestAvailabilityConfiguration, TestAvailabilityConfigurationArgs, TestAvailabilityConfigurationResult](),
+			infer.Function[AssumeImpersonationRole, AssumeImpersonationRoleArgs, AssumeImpersonationRoleResult](),
 		},
 		ModuleMap: map[tokens.ModuleName]tokens.ModuleName{
 			"provider": "index",
@@ -57,7 +83,7 @@ func Provider() p.Provider {
 			},
 			PluginDownloadURL: "github://api.github.com/gothub-team",
 		},
-	})
+	}))
 }
 
 // Each resource has a controlling struct.
@@ -88,13 +114,25 @@ type RandomState struct {
 	Result string `pulumi:"result"`
 }
 
+// Check validates the inputs before they are used.
+func (Random) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (RandomArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args RandomArgs) {
+		c.checkAll("length", func() error {
+			if args.Length < 1 {
+				return fmt.Errorf("length must be positive, got %d", args.Length)
+			}
+			return nil
+		})
+	})
+}
+
 // All resources must implement Create at a minimum.
 func (Random) Create(ctx p.Context, name string, input RandomArgs, preview bool) (string, RandomState, error) {
 	state := RandomState{RandomArgs: input}
 	if preview {
 		return name, state, nil
 	}
-	fmt.Println("Creating random string")
+	ctx.Logf(diag.Debug, "creating random string of length %d", input.Length)
 	state.Result = makeRandom(input.Length)
 	return name, state, nil
 }
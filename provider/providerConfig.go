@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"fmt"
+
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Config is the provider configuration, shared by all resources and functions.
+type Config struct {
+	// The AWS Region used by resources and functions that do not set a region. Defaults
+	// to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The AWS shared config profile used to authenticate.
+	Profile *string `pulumi:"profile,optional"`
+	// The maximum number of attempts of a throttled or failed AWS request. Defaults to 5.
+	MaxAttempts *int `pulumi:"maxAttempts,optional"`
+	// The number of requests per second the provider sends to each AWS API operation.
+	// Defaults to 5.
+	RequestsPerSecond *float64 `pulumi:"requestsPerSecond,optional"`
+	// The number of requests to each AWS API operation that may be sent at once before
+	// requestsPerSecond applies. Defaults to 10.
+	Burst *int `pulumi:"burst,optional"`
+
+	clients *clientCache
+}
+
+// Configure validates the configuration and creates the AWS client cache of the provider.
+func (c *Config) Configure(ctx p.Context) error {
+	if c.MaxAttempts != nil && *c.MaxAttempts <= 0 {
+		return fmt.Errorf("maxAttempts must be at least 1, got %d", *c.MaxAttempts)
+	}
+	if c.RequestsPerSecond != nil && *c.RequestsPerSecond <= 0 {
+		return fmt.Errorf("requestsPerSecond must be greater than 0, got %g", *c.RequestsPerSecond)
+	}
+	if c.Burst != nil && *c.Burst <= 0 {
+		return fmt.Errorf("burst must be at least 1, got %d", *c.Burst)
+	}
+
+	c.clients = newClientCache(c)
+	return nil
+}
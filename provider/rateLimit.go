@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"golang.org/x/time/rate"
+)
+
+// defaultRequestsPerSecond and defaultBurst stay below the default WorkMail quotas of most
+// API operations.
+const (
+	defaultRequestsPerSecond = 5
+	defaultBurst             = 10
+)
+
+// rateLimiter keeps a token bucket per region and API operation. It is shared by all
+// resource operations of the provider, so parallel creates of many resources are spread
+// out instead of running into AWS throttling.
+type rateLimiter struct {
+	requestsPerSecond float64
+	burst             int
+
+	mu      sync.Mutex
+	buckets map[string]*rate.Limiter
+}
+
+func newRateLimiter(c *Config) *rateLimiter {
+	limiter := &rateLimiter{
+		requestsPerSecond: defaultRequestsPerSecond,
+		burst:             defaultBurst,
+		buckets:           map[string]*rate.Limiter{},
+	}
+	if c != nil {
+		limiter.requestsPerSecond = ifNotNil(c.RequestsPerSecond, defaultRequestsPerSecond)
+		limiter.burst = ifNotNil(c.Burst, defaultBurst)
+	}
+	return limiter
+}
+
+func (limiter *rateLimiter) bucket(key string) *rate.Limiter {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	bucket, ok := limiter.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(limiter.requestsPerSecond), limiter.burst)
+		limiter.buckets[key] = bucket
+	}
+	return bucket
+}
+
+// middleware waits for a token before every attempt of a request. It runs after the
+// retry middleware, so retries of throttled requests are limited as well.
+func (limiter *rateLimiter) middleware(region string) func(*middleware.Stack) error {
+	isThrottle := retry.IsErrorThrottles(retry.DefaultThrottles)
+
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("RateLimit", func(
+			ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+		) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			operation := awsmiddleware.GetServiceID(ctx) + "." + awsmiddleware.GetOperationName(ctx)
+
+			start := time.Now()
+			err := limiter.bucket(region + "/" + operation).Wait(ctx)
+			if err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+			waited := time.Since(start)
+
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			if metrics, ok := ctx.Value(requestMetricsKey{}).(*requestMetrics); ok {
+				metrics.record(operation, waited, err != nil && isThrottle.IsErrorThrottle(err).Bool())
+			}
+			return out, metadata, err
+		}), "Retry", middleware.After)
+	}
+}
+
+type requestMetricsKey struct{}
+
+// requestMetrics counts the AWS requests of a single resource operation.
+type requestMetrics struct {
+	mu        sync.Mutex
+	requests  map[string]int
+	throttled int
+	waited    time.Duration
+}
+
+func (metrics *requestMetrics) record(operation string, waited time.Duration, throttled bool) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	metrics.requests[operation]++
+	metrics.waited += waited
+	if throttled {
+		metrics.throttled++
+	}
+}
+
+// log reports the requests of the operation. Operations that were delayed by the rate
+// limiter or throttled by AWS are reported as info, all others only as debug output.
+func (metrics *requestMetrics) log(ctx p.Context) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if len(metrics.requests) == 0 {
+		return
+	}
+
+	operations := make([]string, 0, len(metrics.requests))
+	total := 0
+	for operation, count := range metrics.requests {
+		operations = append(operations, fmt.Sprintf("%s=%d", operation, count))
+		total += count
+	}
+	sort.Strings(operations)
+
+	severity := diag.Debug
+	if metrics.throttled > 0 || metrics.waited >= time.Second {
+		severity = diag.Info
+	}
+	ctx.Logf(severity, "%d AWS requests (%s), %d throttled, %s waited for the rate limit",
+		total, strings.Join(operations, ", "), metrics.throttled, metrics.waited.Round(time.Millisecond))
+}
+
+// withRequestMetrics logs the AWS requests of every resource operation and function call
+// once it has finished.
+func withRequestMetrics(provider p.Provider) p.Provider {
+	provider.Create = measured(provider.Create)
+	provider.Read = measured(provider.Read)
+	provider.Update = measured(provider.Update)
+	provider.Invoke = measured(provider.Invoke)
+	if del := provider.Delete; del != nil {
+		provider.Delete = func(ctx p.Context, req p.DeleteRequest) error {
+			_, err := measured(func(ctx p.Context, req p.DeleteRequest) (struct{}, error) {
+				return struct{}{}, del(ctx, req)
+			})(ctx, req)
+			return err
+		}
+	}
+	return provider
+}
+
+func measured[I, O any](method func(p.Context, I) (O, error)) func(p.Context, I) (O, error) {
+	if method == nil {
+		return nil
+	}
+	return func(ctx p.Context, req I) (O, error) {
+		metrics := &requestMetrics{requests: map[string]int{}}
+		ctx = p.CtxWithValue(ctx, requestMetricsKey{}, metrics)
+		defer metrics.log(ctx)
+		return method(ctx, req)
+	}
+}
@@ -0,0 +1,295 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Each resource has a controlling struct.
+// Resource behavior is determined by implementing methods on the controlling struct.
+// The `Create` method is mandatory, but other methods are optional.
+// - Check: Remap inputs before they are typed.
+// - Diff: Change how instances of a resource are compared.
+// - Update: Mutate a resource in place.
+// - Read: Get the state of a resource from the backing provider.
+// - Delete: Custom logic when the resource is deleted.
+// - Annotate: Describe fields and set defaults for a resource.
+// - WireDependencies: Control how outputs and secrets flows through values.
+type RetentionPolicy struct{}
+
+// Each resource has an input struct, defining what arguments it accepts.
+type RetentionPolicyArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization the retention policy applies to. An organization has a single
+	// retention policy.
+	OrganizationId string `pulumi:"organizationId"`
+	// The retention policy name.
+	Name string `pulumi:"name"`
+	// The retention policy description.
+	Description *string `pulumi:"description,optional"`
+	// The retention policy folder configurations. Each folder may be configured once.
+	FolderConfigurations []FolderConfiguration `pulumi:"folderConfigurations"`
+}
+
+type FolderConfiguration struct {
+	// The folder name.
+	Name RetentionFolderName `pulumi:"name"`
+	// The action to take on the folder contents at the end of the period.
+	Action RetentionAction `pulumi:"action"`
+	// The number of days after which the action applies. Required for DELETE and
+	// PERMANENTLY_DELETE, not allowed for NONE.
+	Period *int `pulumi:"period,optional"`
+}
+
+type RetentionFolderName string
+
+const (
+	RetentionFolderNameInbox        RetentionFolderName = "INBOX"
+	RetentionFolderNameDeletedItems RetentionFolderName = "DELETED_ITEMS"
+	RetentionFolderNameSentItems    RetentionFolderName = "SENT_ITEMS"
+	RetentionFolderNameDrafts       RetentionFolderName = "DRAFTS"
+	RetentionFolderNameJunkEmail    RetentionFolderName = "JUNK_EMAIL"
+)
+
+func (RetentionFolderName) Values() []infer.EnumValue[RetentionFolderName] {
+	return []infer.EnumValue[RetentionFolderName]{
+		{Name: "Inbox", Value: RetentionFolderNameInbox},
+		{Name: "DeletedItems", Value: RetentionFolderNameDeletedItems},
+		{Name: "SentItems", Value: RetentionFolderNameSentItems},
+		{Name: "Drafts", Value: RetentionFolderNameDrafts},
+		{Name: "JunkEmail", Value: RetentionFolderNameJunkEmail},
+	}
+}
+
+type RetentionAction string
+
+const (
+	RetentionActionNone              RetentionAction = "NONE"
+	RetentionActionDelete            RetentionAction = "DELETE"
+	RetentionActionPermanentlyDelete RetentionAction = "PERMANENTLY_DELETE"
+)
+
+func (RetentionAction) Values() []infer.EnumValue[RetentionAction] {
+	return []infer.EnumValue[RetentionAction]{
+		{Name: "None", Value: RetentionActionNone, Description: "Keep the folder contents indefinitely."},
+		{Name: "Delete", Value: RetentionActionDelete, Description: "Move the folder contents to Deleted Items."},
+		{Name: "PermanentlyDelete", Value: RetentionActionPermanentlyDelete, Description: "Permanently delete the folder contents."},
+	}
+}
+
+// Each resource has a state, describing the fields that exist on the created resource.
+type RetentionPolicyState struct {
+	// It is generally a good idea to embed args in outputs, but it isn't strictly necessary.
+	RetentionPolicyArgs
+
+	// The retention policy id.
+	RetentionPolicyId string `pulumi:"retentionPolicyId"`
+}
+
+// Check validates the inputs before they are used.
+func (RetentionPolicy) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (RetentionPolicyArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args RetentionPolicyArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.checkAll("folderConfigurations", func() error { return validateFolderConfigurations(args.FolderConfigurations) })
+	})
+}
+
+// All resources must implement Create at a minimum.
+func (RetentionPolicy) Create(ctx p.Context, name string, input RetentionPolicyArgs, preview bool) (string, RetentionPolicyState, error) {
+	state := RetentionPolicyState{RetentionPolicyArgs: input}
+	if preview {
+		return name, state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return "", state, err
+	}
+
+	err = putRetentionPolicy(ctx, workmailclient, input, nil)
+	if err != nil {
+		return "", state, err
+	}
+
+	// PutRetentionPolicy does not return the id of the created policy.
+	policy, err := workmailclient.GetDefaultRetentionPolicy(ctx, &workmail.GetDefaultRetentionPolicyInput{
+		OrganizationId: &input.OrganizationId,
+	})
+	if err != nil {
+		return "", state, err
+	}
+	state.RetentionPolicyId = *policy.Id
+
+	return state.RetentionPolicyId, state, nil
+}
+
+func (RetentionPolicy) Diff(ctx p.Context, id string, olds RetentionPolicyState, news RetentionPolicyArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  Name
+	if olds.Name != news.Name {
+		diffs["name"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  Description
+	if ptrDiff(olds.Description, news.Description) {
+		diffs["description"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	//  FolderConfigurations
+	if folderConfigurationsDiff(olds.FolderConfigurations, news.FolderConfigurations) {
+		diffs["folderConfigurations"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	// An organization only has a single retention policy, so the old one has to go first.
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs, DeleteBeforeReplace: true}, nil
+}
+
+// folderConfigurationsDiff compares folder configurations by folder, ignoring order.
+func folderConfigurationsDiff(a, b []FolderConfiguration) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	byFolder := make(map[RetentionFolderName]FolderConfiguration, len(a))
+	for _, folder := range a {
+		byFolder[folder.Name] = folder
+	}
+	for _, folder := range b {
+		old, found := byFolder[folder.Name]
+		if !found || old.Action != folder.Action || ptrDiff(old.Period, folder.Period) {
+			return true
+		}
+	}
+	return false
+}
+
+func (RetentionPolicy) Update(ctx p.Context, id string, olds RetentionPolicyState, news RetentionPolicyArgs, preview bool) (RetentionPolicyState, error) {
+	state := RetentionPolicyState{RetentionPolicyArgs: news, RetentionPolicyId: olds.RetentionPolicyId}
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	err = putRetentionPolicy(ctx, workmailclient, news, &state.RetentionPolicyId)
+
+	return state, err
+}
+
+// validateFolderConfigurations ensures each folder is configured once and periods are
+// only set for actions that take effect after a period.
+func validateFolderConfigurations(folders []FolderConfiguration) error {
+	seen := make(map[RetentionFolderName]bool, len(folders))
+	for _, folder := range folders {
+		if seen[folder.Name] {
+			return fmt.Errorf("folder %s is configured more than once", folder.Name)
+		}
+		seen[folder.Name] = true
+
+		switch {
+		case folder.Action == RetentionActionNone && folder.Period != nil:
+			return fmt.Errorf("folder %s: period must not be set for action %s", folder.Name, folder.Action)
+		case folder.Action != RetentionActionNone && folder.Period == nil:
+			return fmt.Errorf("folder %s: period is required for action %s", folder.Name, folder.Action)
+		case folder.Period != nil && (*folder.Period < 1 || *folder.Period > 730):
+			return fmt.Errorf("folder %s: period must be between 1 and 730 days, got %d", folder.Name, *folder.Period)
+		}
+	}
+	return nil
+}
+
+func putRetentionPolicy(ctx p.Context, workmailclient *workmail.Client, input RetentionPolicyArgs, id *string) error {
+	_, err := workmailclient.PutRetentionPolicy(ctx, &workmail.PutRetentionPolicyInput{
+		OrganizationId: &input.OrganizationId,
+		Id:             id,
+		Name:           &input.Name,
+		Description:    input.Description,
+		FolderConfigurations: Map(func(folder FolderConfiguration) types.FolderConfiguration {
+			var period *int32
+			if folder.Period != nil {
+				days := int32(*folder.Period)
+				period = &days
+			}
+			return types.FolderConfiguration{
+				Name:   types.FolderName(folder.Name),
+				Action: types.RetentionAction(folder.Action),
+				Period: period,
+			}
+		})(input.FolderConfigurations),
+	})
+
+	return err
+}
+
+func (RetentionPolicy) Read(ctx p.Context, id string, inputs RetentionPolicyArgs, state RetentionPolicyState) (string, RetentionPolicyArgs, RetentionPolicyState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	policy, err := workmailclient.GetDefaultRetentionPolicy(ctx, &workmail.GetDefaultRetentionPolicyInput{
+		OrganizationId: &state.OrganizationId,
+	})
+	if err != nil {
+		return "", inputs, state, err
+	}
+	if policy.Id == nil || *policy.Id != id {
+		// The policy has been deleted or replaced outside of pulumi.
+		return "", inputs, state, nil
+	}
+
+	state.Name = ifNotNil(policy.Name, "")
+	state.Description = policy.Description
+	state.FolderConfigurations = Map(func(folder types.FolderConfiguration) FolderConfiguration {
+		var period *int
+		if folder.Period != nil {
+			days := int(*folder.Period)
+			period = &days
+		}
+		return FolderConfiguration{
+			Name:   RetentionFolderName(folder.Name),
+			Action: RetentionAction(folder.Action),
+			Period: period,
+		}
+	})(policy.FolderConfigurations)
+
+	return id, state.RetentionPolicyArgs, state, nil
+}
+
+// The Delete method will run when the resource is deleted.
+func (RetentionPolicy) Delete(ctx p.Context, id string, props RetentionPolicyState) error {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = workmailclient.DeleteRetentionPolicy(ctx, &workmail.DeleteRetentionPolicyInput{
+		OrganizationId: &props.OrganizationId,
+		Id:             &id,
+	})
+
+	return err
+}
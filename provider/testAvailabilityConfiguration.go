@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/workmail"
+	p "github.com/pulumi/pulumi-go-provider"
+)
+
+// Each function has a controlling struct.
+// Function behavior is determined by implementing the `Call` method on the controlling struct.
+type TestAvailabilityConfiguration struct{}
+
+// Each function has an input struct, defining what arguments it accepts.
+type TestAvailabilityConfigurationArgs struct {
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
+	// The organization id.
+	OrganizationId string `pulumi:"organizationId"`
+	// The domain of an existing availability configuration to test. Either domainName or
+	// a provider (ewsEndpoint, ewsUsername, ewsPassword or lambdaArn) must be specified.
+	DomainName *string `pulumi:"domainName,optional"`
+	// The EWS endpoint of an Exchange availability provider to test.
+	EwsEndpoint *string `pulumi:"ewsEndpoint,optional"`
+	// The username used to authenticate against the EWS endpoint.
+	EwsUsername *string `pulumi:"ewsUsername,optional"`
+	// The password used to authenticate against the EWS endpoint.
+	EwsPassword *string `pulumi:"ewsPassword,optional" provider:"secret"`
+	// The ARN of the Lambda function of a custom availability provider to test.
+	LambdaArn *string `pulumi:"lambdaArn,optional"`
+}
+
+// Each function has a result struct, describing the fields that are returned.
+type TestAvailabilityConfigurationResult struct {
+	// Whether the availability provider could be reached.
+	TestPassed bool `pulumi:"testPassed"`
+	// The reason the test failed.
+	FailureReason *string `pulumi:"failureReason,optional"`
+}
+
+// Call tests an existing or prospective availability configuration.
+func (TestAvailabilityConfiguration) Call(ctx p.Context, input TestAvailabilityConfigurationArgs) (TestAvailabilityConfigurationResult, error) {
+	result := TestAvailabilityConfigurationResult{}
+
+	request := &workmail.TestAvailabilityConfigurationInput{
+		OrganizationId: &input.OrganizationId,
+		DomainName:     input.DomainName,
+	}
+	if input.DomainName == nil {
+		ewsProvider, lambdaProvider, err := availabilityProviders(input.EwsEndpoint, input.EwsUsername, input.EwsPassword, input.LambdaArn)
+		if err != nil {
+			return result, err
+		}
+		request.EwsProvider = ewsProvider
+		request.LambdaProvider = lambdaProvider
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
+	if err != nil {
+		return result, err
+	}
+
+	test, err := workmailclient.TestAvailabilityConfiguration(ctx, request)
+	if err != nil {
+		return result, err
+	}
+
+	result.TestPassed = test.TestPassed
+	result.FailureReason = test.FailureReason
+
+	return result, nil
+}
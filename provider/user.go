@@ -1,13 +1,19 @@
 package provider
 
 import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/workmail"
 	"github.com/aws/aws-sdk-go-v2/service/workmail/types"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"golang.org/x/crypto/argon2"
 )
 
 // Each resource has a controlling struct.
@@ -24,8 +30,8 @@ type User struct{}
 
 // Each resource has an input struct, defining what arguments it accepts.
 type UserArgs struct {
-	// The AWS Region. TODO: This should be passed as a pulumi.Provider
-	Region string `pulumi:"region"`
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
 	// The display name for the new user.
 	DisplayName string `pulumi:"displayName"`
 	// The name for the new user. WorkMail directory user names have a maximum length
@@ -45,8 +51,17 @@ type UserArgs struct {
 	HiddenFromGlobalAddressList *bool `pulumi:"hiddenFromGlobalAddressList,optional"`
 	// The last name of the new user.
 	LastName *string `pulumi:"lastName,optional"`
-	// The password for the new user.
-	Password *string `pulumi:"password,optional"`
+	// The password for the new user. Changing the password resets it without replacing the
+	// user.
+	Password *string `pulumi:"password,optional" provider:"secret"`
+	// Whether the password is kept out of the outputs of the user. Changes of the password
+	// are detected through a salted hash of it instead. The engine still records the
+	// password in the inputs of the user, encrypted like all secrets.
+	PasswordWriteOnly *bool `pulumi:"passwordWriteOnly,optional"`
+	// The id of the IAM Identity Center user the user signs in as. When omitted, the user
+	// is mapped automatically on the first sign in through Identity Center. Requires an
+	// IdentityProviderConfiguration on the organization.
+	IdentityProviderUserId *string `pulumi:"identityProviderUserId,optional"`
 	// The role of the new user.
 	//
 	// You cannot pass SYSTEM_USER or RESOURCE role in a single request. When a user
@@ -74,6 +89,24 @@ type UserState struct {
 	UserId string `pulumi:"userId"`
 	// The organization id.
 	OrganizationId string `pulumi:"organizationId"`
+	// The current size of the user's mailbox in megabytes.
+	CurrentMailboxSizeMb *float64 `pulumi:"currentMailboxSizeMb,optional"`
+	// The current maximum size of the user's mailbox in megabytes, set through the
+	// mailboxQuotaMb of the WorkmailRegistration of the user.
+	CurrentMailboxQuotaMb *int `pulumi:"currentMailboxQuotaMb,optional"`
+	// The id of the IAM Identity Center identity store the user is mapped to.
+	IdentityProviderIdentityStoreId *string `pulumi:"identityProviderIdentityStoreId,optional"`
+	// The salted argon2id hash of the password when passwordWriteOnly is set.
+	PasswordHash *string `pulumi:"passwordHash,optional" provider:"secret"`
+}
+
+// Check validates the inputs before they are used.
+func (User) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (UserArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args UserArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("name", args.Name, validateUserName)
+		c.checkOrganizationOrDomain(args.OrganizationId, args.Domain)
+	})
 }
 
 // All resources must implement Create at a minimum.
@@ -83,36 +116,24 @@ func (User) Create(ctx p.Context, name string, input UserArgs, preview bool) (st
 		return name, state, nil
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
 	if err != nil {
 		return "", state, err
 	}
-	cfg.Region = input.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	// Find organization
-	if input.OrganizationId == nil && input.Domain == nil {
-		return "", state, errors.New("either organizationId or domain must be specified")
+	state.OrganizationId, err = resolveOrganizationId(ctx, workmailclient, input.OrganizationId, input.Domain)
+	if err != nil {
+		return "", state, err
 	}
-	if input.Domain != nil {
-		organizations, err := workmailclient.ListOrganizations(ctx, &workmail.ListOrganizationsInput{})
+
+	// Only the password and the Identity Center mapping depend on the authentication mode.
+	if input.Password != nil || input.IdentityProviderUserId != nil {
+		err = validateAuthenticationMode(ctx, workmailclient, state)
 		if err != nil {
 			return "", state, err
 		}
-		organization, found := Find(func(org types.OrganizationSummary) bool {
-			if org.DefaultMailDomain == nil || input.Domain == nil {
-				return false
-			}
-			return *org.DefaultMailDomain == *input.Domain
-		})(organizations.OrganizationSummaries)
-		if !found {
-			return "", state, fmt.Errorf("no workmail organization with domain %s found", *input.Domain)
-		}
-		state.OrganizationId = *organization.OrganizationId
-	} else {
-		state.OrganizationId = *input.OrganizationId
 	}
 
 	// Create the organization
@@ -124,6 +145,7 @@ func (User) Create(ctx p.Context, name string, input UserArgs, preview bool) (st
 		LastName:                    input.LastName,
 		Password:                    input.Password,
 		HiddenFromGlobalAddressList: ifNotNil(input.HiddenFromGlobalAddressList, false),
+		IdentityProviderUserId:      input.IdentityProviderUserId,
 		// Role:                        types.UserRoleUser,
 	})
 	if err != nil {
@@ -131,10 +153,38 @@ func (User) Create(ctx p.Context, name string, input UserArgs, preview bool) (st
 	}
 
 	state.UserId = *user.UserId
+	err = storePassword(&state, nil)
+	if err != nil {
+		return "", state, err
+	}
 
 	return *user.UserId, state, nil
 }
 
+// validateAuthenticationMode ensures the way the user signs in is supported by the
+// identity provider configuration of the organization. It requires the
+// workmail:DescribeIdentityProviderConfiguration permission.
+func validateAuthenticationMode(ctx p.Context, workmailclient *workmail.Client, state UserState) error {
+	configuration, err := workmailclient.DescribeIdentityProviderConfiguration(ctx, &workmail.DescribeIdentityProviderConfigurationInput{
+		OrganizationId: &state.OrganizationId,
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		if state.IdentityProviderUserId != nil {
+			return fmt.Errorf("user %s is mapped to an IAM Identity Center user, but organization %s has no identity provider configuration", state.Name, state.OrganizationId)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if configuration.AuthenticationMode == types.IdentityProviderAuthenticationModeIdentityProviderOnly && state.Password != nil {
+		return fmt.Errorf("user %s has a password, but organization %s only allows signing in with IAM Identity Center", state.Name, state.OrganizationId)
+	}
+	return nil
+}
+
 func (User) Diff(ctx p.Context, id string, olds UserState, news UserArgs) (p.DiffResponse, error) {
 	diffs := make(map[string]p.PropertyDiff)
 	hasChanges := false
@@ -182,7 +232,7 @@ func (User) Diff(ctx p.Context, id string, olds UserState, news UserArgs) (p.Dif
 	}
 
 	//  Region
-	if ptrDiff(&olds.Region, &news.Region) {
+	if ptrDiff(olds.Region, news.Region) {
 		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
 		hasChanges = true
 	}
@@ -218,8 +268,20 @@ func (User) Diff(ctx p.Context, id string, olds UserState, news UserArgs) (p.Dif
 	}
 
 	//  Password
-	if ptrDiff(olds.Password, news.Password) {
-		diffs["password"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	if passwordDiff(olds, news) {
+		diffs["password"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+		hasChanges = true
+	}
+
+	//  PasswordWriteOnly
+	if ifNotNil(olds.PasswordWriteOnly, false) != ifNotNil(news.PasswordWriteOnly, false) {
+		diffs["passwordWriteOnly"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+		hasChanges = true
+	}
+
+	//  IdentityProviderUserId
+	if ptrDiff(olds.IdentityProviderUserId, news.IdentityProviderUserId) {
+		diffs["identityProviderUserId"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
 		hasChanges = true
 	}
 
@@ -236,58 +298,129 @@ func ptrDiff[T comparable](a, b *T) bool {
 	return *a != *b
 }
 
-// func (User) Update(ctx p.Context, id string, olds UserState, props UserArgs, preview bool) (UserState, error) {
-// 	state := UserState{UserArgs: props}
-
-// 	// If in preview, don't run the command.
-// 	if preview {
-// 		return state, nil
-// 	}
-
-// 	cfg, err := config.LoadDefaultConfig(ctx)
-// 	if err != nil {
-// 		return state, err
-// 	}
-// 	cfg.Region = props.Region
-
-// 	// Create the WorkMail service client using the config
-// 	workmailclient := workmail.NewFromConfig(cfg)
-
-// 	// _, err = workmailclient.UpdateUser(ctx, &workmail.UpdateUserInput{
-// 	// 	UserId:                      &id,
-// 	// 	OrganizationId:              &olds.OrganizationId,
-// 	// 	FirstName:                   props.FirstName,
-// 	// 	LastName:                    props.LastName,
-// 	// 	HiddenFromGlobalAddressList: props.HiddenFromGlobalAddressList,
-// 	// })
-// 	// if err != nil {
-// 	// 	return state, err
-// 	// }
-
-// 	if ptrDiff(&olds.Password, &props.Password) {
-// 		_, err = workmailclient.ResetPassword(ctx, &workmail.ResetPasswordInput{
-// 			OrganizationId: &olds.OrganizationId,
-// 			UserId:         &id,
-// 			Password:       props.Password,
-// 		})
-// 		if err != nil {
-// 			return state, err
-// 		}
-// 	}
-
-// 	return state, err
-// }
+// Update applies in-place changes. Every other change replaces the user.
+func (User) Update(ctx p.Context, id string, olds UserState, news UserArgs, preview bool) (UserState, error) {
+	state := olds
+	state.UserArgs = news
+	if preview {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	// Removing the password keeps the current password of the user.
+	passwordChanged := passwordDiff(olds, news)
+	if news.Password != nil && passwordChanged {
+		_, err = workmailclient.ResetPassword(ctx, &workmail.ResetPasswordInput{
+			OrganizationId: &state.OrganizationId,
+			UserId:         &state.UserId,
+			Password:       news.Password,
+		})
+		if err != nil {
+			return state, err
+		}
+	}
+
+	var previousHash *string
+	if !passwordChanged {
+		previousHash = olds.PasswordHash
+	}
+	err = storePassword(&state, previousHash)
+	if err != nil {
+		return state, err
+	}
+
+	if ptrDiff(olds.IdentityProviderUserId, news.IdentityProviderUserId) {
+		err = validateAuthenticationMode(ctx, workmailclient, state)
+		if err != nil {
+			return state, err
+		}
+		// An empty id removes the mapping.
+		identityProviderUserId := ifNotNil(news.IdentityProviderUserId, "")
+		_, err = workmailclient.UpdateUser(ctx, &workmail.UpdateUserInput{
+			OrganizationId:         &state.OrganizationId,
+			UserId:                 &state.UserId,
+			IdentityProviderUserId: &identityProviderUserId,
+		})
+		if err != nil {
+			return state, err
+		}
+	}
+
+	return state, nil
+}
+
+// readMailboxDetails refreshes the current mailbox size and quota of a registered user.
+func readMailboxDetails(ctx p.Context, workmailclient *workmail.Client, state *UserState) error {
+	mailbox, err := workmailclient.GetMailboxDetails(ctx, &workmail.GetMailboxDetailsInput{
+		OrganizationId: &state.OrganizationId,
+		UserId:         &state.UserId,
+	})
+	var notRegistered *types.EntityStateException
+	if errors.As(err, &notRegistered) {
+		state.CurrentMailboxSizeMb = nil
+		state.CurrentMailboxQuotaMb = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	state.CurrentMailboxSizeMb = &mailbox.MailboxSize
+	if mailbox.MailboxQuota != nil {
+		quota := int(*mailbox.MailboxQuota)
+		state.CurrentMailboxQuotaMb = &quota
+	}
+	return nil
+}
+
+func (User) Read(ctx p.Context, id string, inputs UserArgs, state UserState) (string, UserArgs, UserState, error) {
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, state.Region)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	user, err := workmailclient.DescribeUser(ctx, &workmail.DescribeUserInput{
+		OrganizationId: &state.OrganizationId,
+		UserId:         &id,
+	})
+	var notFound *types.EntityNotFoundException
+	if errors.As(err, &notFound) {
+		// The user has been deleted outside of pulumi.
+		return "", inputs, state, nil
+	}
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	err = readMailboxDetails(ctx, workmailclient, &state)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.IdentityProviderIdentityStoreId = user.IdentityProviderIdentityStoreId
+	// Only track mapping drift when the mapping is managed by pulumi, since unmapped users
+	// are mapped automatically on their first sign in.
+	if state.IdentityProviderUserId != nil {
+		state.IdentityProviderUserId = user.IdentityProviderUserId
+		inputs.IdentityProviderUserId = user.IdentityProviderUserId
+	}
+
+	return id, inputs, state, nil
+}
 
 // The Delete method will run when the resource is deleted.
 func (User) Delete(ctx p.Context, id string, props UserState) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
 	if err != nil {
 		return err
 	}
-	cfg.Region = props.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	user, err := workmailclient.DescribeUser(ctx, &workmail.DescribeUserInput{
 		OrganizationId: &props.OrganizationId,
@@ -308,6 +441,21 @@ func (User) Delete(ctx p.Context, id string, props UserState) error {
 	return err
 }
 
+// ensureUsersExist returns an error naming the first of the given users that does not
+// exist in the organization.
+func ensureUsersExist(ctx p.Context, workmailclient *workmail.Client, organizationId string, userIds []string) error {
+	for _, userId := range userIds {
+		_, err := workmailclient.DescribeUser(ctx, &workmail.DescribeUserInput{
+			OrganizationId: &organizationId,
+			UserId:         &userId,
+		})
+		if err != nil {
+			return fmt.Errorf("user %s: %w", userId, err)
+		}
+	}
+	return nil
+}
+
 // Find returns a function that takes a slice of type T and returns the first element
 // that satisfies the predicate function, along with a boolean indicating if an element was found.
 func Find[T any](predicate func(T) bool) func([]T) (T, bool) {
@@ -321,3 +469,89 @@ func Find[T any](predicate func(T) bool) func([]T) (T, bool) {
 		return zero, false // return zero value and false if no element is found
 	}
 }
+
+// WireDependencies keeps the password and its hash secret, even when the password is not
+// passed as a secret.
+func (User) WireDependencies(f infer.FieldSelector, args *UserArgs, state *UserState) {
+	f.OutputField(&state.Password).AlwaysSecret()
+	f.OutputField(&state.PasswordHash).AlwaysSecret()
+}
+
+// passwordDiff compares the new password with the password or, in write-only mode, the
+// password hash of the user.
+func passwordDiff(olds UserState, news UserArgs) bool {
+	if olds.PasswordHash != nil {
+		return news.Password == nil || !passwordMatchesHash(*news.Password, *olds.PasswordHash)
+	}
+	return ptrDiff(olds.Password, news.Password)
+}
+
+// storePassword replaces the password of the state with its hash in write-only mode. The
+// previous hash of an unchanged password is kept, so the state only changes with the
+// password.
+func storePassword(state *UserState, previousHash *string) error {
+	if !ifNotNil(state.PasswordWriteOnly, false) || state.Password == nil {
+		state.PasswordHash = nil
+		return nil
+	}
+
+	if previousHash != nil {
+		state.PasswordHash = previousHash
+	} else {
+		hash, err := hashPassword(*state.Password)
+		if err != nil {
+			return err
+		}
+		state.PasswordHash = &hash
+	}
+	state.Password = nil
+	return nil
+}
+
+// Parameters of the argon2id hash of the password, the lowest memory cost recommended by
+// OWASP. The hash only detects changes of a password that the engine keeps in the inputs
+// anyway, and every diff of a write-only user computes it, so it stays cheap enough to diff
+// hundreds of users in parallel.
+const (
+	argon2Time    = 5
+	argon2Memory  = 7 * 1024
+	argon2Threads = 1
+	argon2KeyLen  = 32
+)
+
+// hashPassword returns a salted argon2id hash of the password in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>.
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	_, err := cryptorand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	return formatPasswordHash(salt, password, argon2Time, argon2Memory, argon2Threads), nil
+}
+
+func formatPasswordHash(salt []byte, password string, time, memory uint32, threads uint8) string {
+	key := argon2.IDKey([]byte(password), salt, time, memory, threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// passwordMatchesHash hashes the password with the salt and parameters of the hash, so
+// hashes keep matching if the parameters change.
+func passwordMatchesHash(password, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return false
+	}
+	var time, memory uint32
+	var threads uint8
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads)
+	if err != nil || time == 0 || memory == 0 || threads == 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(formatPasswordHash(salt, password, time, memory, threads)), []byte(hash)) == 1
+}
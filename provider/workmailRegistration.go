@@ -1,11 +1,11 @@
 package provider
 
 import (
-	"fmt"
-
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/workmail"
 	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
 // Each resource has a controlling struct.
@@ -22,8 +22,8 @@ type WorkmailRegistration struct{}
 
 // Each resource has an input struct, defining what arguments it accepts.
 type WorkmailRegistrationArgs struct {
-	// The AWS Region. TODO: This should be passed as a pulumi.Provider
-	Region string `pulumi:"region"`
+	// The AWS Region. Defaults to the region of the AWS configuration.
+	Region *string `pulumi:"region,optional"`
 	// The organization id.
 	OrganizationId string `pulumi:"organizationId"`
 	// The identifier for the user, group, or resource to be updated.
@@ -35,6 +35,9 @@ type WorkmailRegistrationArgs struct {
 	// The email prefix for the new user. (prefix@domain.com).
 	// The default domain of the organization will be appended automatically.
 	EmailPrefix string `pulumi:"emailPrefix"`
+	// The maximum size of the mailbox of the registered user in megabytes. Users have no
+	// mailbox before they are registered, so the quota is applied right after registration.
+	MailboxQuotaMb *int `pulumi:"mailboxQuotaMb,optional"`
 }
 
 // Each resource has a state, describing the fields that exist on the created resource.
@@ -43,6 +46,14 @@ type WorkmailRegistrationState struct {
 	WorkmailRegistrationArgs
 }
 
+// Check validates the inputs before they are used.
+func (WorkmailRegistration) Check(ctx p.Context, name string, oldInputs, newInputs resource.PropertyMap) (WorkmailRegistrationArgs, []p.CheckFailure, error) {
+	return checkInputs(newInputs, func(c *inputChecker, args WorkmailRegistrationArgs) {
+		c.checkOptional("region", args.Region, validateRegion)
+		c.check("emailPrefix", args.EmailPrefix, validateEmailPrefix)
+	})
+}
+
 // All resources must implement Create at a minimum.
 func (WorkmailRegistration) Create(ctx p.Context, name string, input WorkmailRegistrationArgs, preview bool) (string, WorkmailRegistrationState, error) {
 	state := WorkmailRegistrationState{WorkmailRegistrationArgs: input}
@@ -50,14 +61,11 @@ func (WorkmailRegistration) Create(ctx p.Context, name string, input WorkmailReg
 		return name, state, nil
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, input.Region)
 	if err != nil {
 		return "", state, err
 	}
-	cfg.Region = input.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	organization, err := workmailclient.DescribeOrganization(ctx, &workmail.DescribeOrganizationInput{
 		OrganizationId: &state.OrganizationId,
@@ -67,7 +75,7 @@ func (WorkmailRegistration) Create(ctx p.Context, name string, input WorkmailReg
 	}
 
 	emailAddress := input.EmailPrefix + "@" + *organization.DefaultMailDomain
-	fmt.Println("Creating primary email address", emailAddress)
+	ctx.Logf(diag.Info, "registering %s to WorkMail with primary email address %s", input.EntityId, emailAddress)
 	_, err = workmailclient.RegisterToWorkMail(ctx, &workmail.RegisterToWorkMailInput{
 		OrganizationId: &state.OrganizationId,
 		EntityId:       &input.EntityId,
@@ -77,19 +85,81 @@ func (WorkmailRegistration) Create(ctx p.Context, name string, input WorkmailReg
 		return "", state, err
 	}
 
+	if input.MailboxQuotaMb != nil {
+		err = applyMailboxQuota(ctx, workmailclient, state)
+		if err != nil {
+			// The entity is registered, so keep it in the state.
+			return input.EntityId, state, infer.ResourceInitFailedError{Reasons: []string{err.Error()}}
+		}
+	}
+
 	return input.EntityId, state, nil
 }
 
+func (WorkmailRegistration) Diff(ctx p.Context, id string, olds WorkmailRegistrationState, news WorkmailRegistrationArgs) (p.DiffResponse, error) {
+	diffs := make(map[string]p.PropertyDiff)
+
+	//  Region
+	if ptrDiff(olds.Region, news.Region) {
+		diffs["region"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  OrganizationId
+	if olds.OrganizationId != news.OrganizationId {
+		diffs["organizationId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  EntityId
+	if olds.EntityId != news.EntityId {
+		diffs["entityId"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  EmailPrefix
+	if olds.EmailPrefix != news.EmailPrefix {
+		diffs["emailPrefix"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	//  MailboxQuotaMb
+	if ptrDiff(olds.MailboxQuotaMb, news.MailboxQuotaMb) {
+		diffs["mailboxQuotaMb"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{HasChanges: len(diffs) > 0, DetailedDiff: diffs}, nil
+}
+
+func (WorkmailRegistration) Update(ctx p.Context, id string, olds WorkmailRegistrationState, news WorkmailRegistrationArgs, preview bool) (WorkmailRegistrationState, error) {
+	state := WorkmailRegistrationState{WorkmailRegistrationArgs: news}
+	if preview || news.MailboxQuotaMb == nil {
+		return state, nil
+	}
+
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, news.Region)
+	if err != nil {
+		return state, err
+	}
+
+	return state, applyMailboxQuota(ctx, workmailclient, state)
+}
+
+// applyMailboxQuota sets the quota of the mailbox created by the registration.
+func applyMailboxQuota(ctx p.Context, workmailclient *workmail.Client, state WorkmailRegistrationState) error {
+	quota := int32(*state.MailboxQuotaMb)
+	_, err := workmailclient.UpdateMailboxQuota(ctx, &workmail.UpdateMailboxQuotaInput{
+		OrganizationId: &state.OrganizationId,
+		UserId:         &state.EntityId,
+		MailboxQuota:   &quota,
+	})
+	return err
+}
+
 // The Delete method will run when the resource is deleted.
 func (WorkmailRegistration) Delete(ctx p.Context, id string, props WorkmailRegistrationState) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// Create the WorkMail service client for the region
+	workmailclient, err := workmailClient(ctx, props.Region)
 	if err != nil {
 		return err
 	}
-	cfg.Region = props.Region
-
-	// Create the WorkMail service client using the config
-	workmailclient := workmail.NewFromConfig(cfg)
 
 	_, err = workmailclient.DeregisterFromWorkMail(ctx, &workmail.DeregisterFromWorkMailInput{
 		OrganizationId: &props.OrganizationId,
@@ -15,9 +15,17 @@
 package tests
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/blang/semver"
 	p "github.com/pulumi/pulumi-go-provider"
 	"github.com/pulumi/pulumi-go-provider/integration"
@@ -146,6 +154,66 @@ func TestUser(t *testing.T) {
 	})
 }
 
+func TestOrganizationDiff(t *testing.T) {
+	prov := provider()
+
+	Convey("When changing the KMS key of an organization", t, func() {
+		olds := resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"alias":          resource.NewStringProperty("gothub"),
+			"organizationId": resource.NewStringProperty("m-123"),
+		}
+		news := olds.Copy()
+		delete(news, "organizationId")
+		news["kmsKeyArn"] = resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("Organization"), ID: "m-123", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.DetailedDiff, ShouldHaveLength, 1)
+		So(diff.DetailedDiff["kmsKeyArn"].Kind, ShouldEqual, p.UpdateReplace)
+	})
+}
+
+func TestUserMailboxQuotaDiff(t *testing.T) {
+	prov := provider()
+
+	Convey("When changing the mailbox quota of a registered user", t, func() {
+		olds := resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"entityId":       resource.NewStringProperty("USER_ID"),
+			"emailPrefix":    resource.NewStringProperty("info"),
+			"mailboxQuotaMb": resource.NewNumberProperty(50000),
+		}
+		news := olds.Copy()
+		news["mailboxQuotaMb"] = resource.NewNumberProperty(100000)
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("WorkmailRegistration"), ID: "USER_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeTrue)
+		So(diff.DetailedDiff, ShouldHaveLength, 1)
+		So(diff.DetailedDiff["mailboxQuotaMb"].Kind, ShouldEqual, p.Update)
+	})
+
+	Convey("When mapping a user to an IAM Identity Center user", t, func() {
+		olds := resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"userId":         resource.NewStringProperty("USER_ID"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"displayName":    resource.NewStringProperty("Info"),
+			"name":           resource.NewStringProperty("Info"),
+		}
+		news := olds.Copy()
+		delete(news, "userId")
+		news["identityProviderUserId"] = resource.NewStringProperty("IDENTITY_CENTER_USER_ID")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("User"), ID: "USER_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.DetailedDiff, ShouldHaveLength, 1)
+		So(diff.DetailedDiff["identityProviderUserId"].Kind, ShouldEqual, p.Update)
+	})
+}
+
 func TestDeleteUser(t *testing.T) {
 	prov := provider()
 
@@ -208,6 +276,1553 @@ func TestDeleteWorkmailRegistration(t *testing.T) {
 	})
 }
 
+func TestGetOrganization(t *testing.T) {
+	prov := provider()
+
+	Convey("When looking up an organization by domain", t, func() {
+		organization, err := prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:getOrganization",
+			Args: resource.PropertyMap{
+				"region": resource.NewStringProperty("eu-west-1"),
+				"domain": resource.NewStringProperty("dev.gothub.io"),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		So(organization.Failures, ShouldBeEmpty)
+		So(organization.Return["organizationId"].StringValue(), ShouldNotBeEmpty)
+
+		Convey("When looking up the mail domain", func() {
+			domain, err := prov.Invoke(p.InvokeRequest{
+				Token: "awsworkmail:index:getMailDomain",
+				Args: resource.PropertyMap{
+					"region":         resource.NewStringProperty("eu-west-1"),
+					"organizationId": organization.Return["organizationId"],
+					"domainName":     resource.NewStringProperty("dev.gothub.io"),
+				},
+			})
+
+			So(err, ShouldBeNil)
+			So(domain.Return["records"].ArrayValue(), ShouldHaveLength, 8)
+		})
+
+		Convey("When looking up a user by name", func() {
+			user, err := prov.Invoke(p.InvokeRequest{
+				Token: "awsworkmail:index:getUser",
+				Args: resource.PropertyMap{
+					"region":         resource.NewStringProperty("eu-west-1"),
+					"organizationId": organization.Return["organizationId"],
+					"name":           resource.NewStringProperty("Info"),
+				},
+			})
+
+			So(err, ShouldBeNil)
+			So(user.Return["userId"].StringValue(), ShouldNotBeEmpty)
+		})
+	})
+}
+
+func TestMobileDeviceAccessRule(t *testing.T) {
+	prov := provider()
+
+	Convey("When creating a mobile device access rule", t, func() {
+		properties := resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"name":           resource.NewStringProperty("deny-unmanaged-phones"),
+			"effect":         resource.NewStringProperty("DENY"),
+			"deviceTypes": resource.NewArrayProperty([]resource.PropertyValue{
+				resource.NewStringProperty("iPhone"),
+			}),
+		}
+		rule, err := prov.Create(p.CreateRequest{
+			Urn:        urn("MobileDeviceAccessRule"),
+			Properties: properties,
+			Preview:    false,
+		})
+
+		So(err, ShouldBeNil)
+		So(rule.Properties["mobileDeviceAccessRuleId"].StringValue(), ShouldNotBeEmpty)
+
+		Convey("When changing the effect", func() {
+			news := properties.Copy()
+			news["effect"] = resource.NewStringProperty("ALLOW")
+			diff, err := prov.Diff(p.DiffRequest{
+				Urn:  urn("MobileDeviceAccessRule"),
+				ID:   rule.ID,
+				Olds: rule.Properties,
+				News: news,
+			})
+
+			So(err, ShouldBeNil)
+			So(diff.HasChanges, ShouldBeTrue)
+			So(diff.DetailedDiff["effect"].Kind, ShouldEqual, p.Update)
+		})
+
+		err = prov.Delete(p.DeleteRequest{
+			Urn:        urn("MobileDeviceAccessRule"),
+			Properties: rule.Properties,
+			ID:         rule.ID,
+		})
+
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestAccessControlRuleInvalidIpRange(t *testing.T) {
+	prov := provider()
+
+	Convey("When checking an access control rule with an invalid IP range", t, func() {
+		response, err := prov.Check(p.CheckRequest{
+			Urn: urn("AccessControlRule"),
+			News: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+				"name":           resource.NewStringProperty("office-only"),
+				"description":    resource.NewStringProperty("Only allow IMAP from the office"),
+				"effect":         resource.NewStringProperty("ALLOW"),
+				"ipRanges": resource.NewArrayProperty([]resource.PropertyValue{
+					resource.NewStringProperty("10.0.0.300/24"),
+				}),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		So(response.Failures, ShouldHaveLength, 1)
+		So(response.Failures[0].Property, ShouldEqual, "ipRanges")
+		So(response.Failures[0].Reason, ShouldContainSubstring, "10.0.0.300/24")
+	})
+}
+
+func TestMailboxPermissionDiff(t *testing.T) {
+	prov := provider()
+
+	permissions := func(values ...string) resource.PropertyValue {
+		array := []resource.PropertyValue{}
+		for _, value := range values {
+			array = append(array, resource.NewStringProperty(value))
+		}
+		return resource.NewArrayProperty(array)
+	}
+	olds := resource.PropertyMap{
+		"region":         resource.NewStringProperty("eu-west-1"),
+		"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+		"entityId":       resource.NewStringProperty("SHARED_MAILBOX_ID"),
+		"granteeId":      resource.NewStringProperty("USER_ID"),
+		"permissions":    permissions("FULL_ACCESS", "SEND_AS"),
+	}
+
+	Convey("When reordering mailbox permissions", t, func() {
+		news := olds.Copy()
+		news["permissions"] = permissions("SEND_AS", "FULL_ACCESS")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("MailboxPermission"), ID: "SHARED_MAILBOX_ID/USER_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeFalse)
+	})
+
+	Convey("When adding a mailbox permission", t, func() {
+		news := olds.Copy()
+		news["permissions"] = permissions("FULL_ACCESS", "SEND_AS", "SEND_ON_BEHALF")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("MailboxPermission"), ID: "SHARED_MAILBOX_ID/USER_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeTrue)
+		So(diff.DetailedDiff["permissions"].Kind, ShouldEqual, p.Update)
+	})
+}
+
+func TestEmailMonitoringConfigurationDiff(t *testing.T) {
+	prov := provider()
+
+	olds := resource.PropertyMap{
+		"region":         resource.NewStringProperty("eu-west-1"),
+		"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+		"roleArn":        resource.NewStringProperty("arn:aws:iam::123456789012:role/workmail-monitoring"),
+		"logGroupArn":    resource.NewStringProperty("arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/email"),
+	}
+
+	Convey("When nothing changes", t, func() {
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("EmailMonitoringConfiguration"), ID: "ORGANIZATION_ID", Olds: olds, News: olds.Copy()})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeFalse)
+	})
+
+	Convey("When changing the log group", t, func() {
+		news := olds.Copy()
+		news["logGroupArn"] = resource.NewStringProperty("arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/archive")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("EmailMonitoringConfiguration"), ID: "ORGANIZATION_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeTrue)
+		So(diff.DetailedDiff["logGroupArn"].Kind, ShouldEqual, p.Update)
+	})
+
+	Convey("When moving the configuration to another organization", t, func() {
+		news := olds.Copy()
+		news["organizationId"] = resource.NewStringProperty("OTHER_ORGANIZATION_ID")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("EmailMonitoringConfiguration"), ID: "ORGANIZATION_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeTrue)
+		So(diff.DetailedDiff["organizationId"].Kind, ShouldEqual, p.UpdateReplace)
+	})
+}
+
+func TestImpersonationRoleDiff(t *testing.T) {
+	prov := provider()
+
+	rule := func(effect string, targetUsers ...string) resource.PropertyValue {
+		users := []resource.PropertyValue{}
+		for _, user := range targetUsers {
+			users = append(users, resource.NewStringProperty(user))
+		}
+		return resource.NewObjectProperty(resource.PropertyMap{
+			"effect":      resource.NewStringProperty(effect),
+			"targetUsers": resource.NewArrayProperty(users),
+		})
+	}
+	olds := resource.PropertyMap{
+		"region":              resource.NewStringProperty("eu-west-1"),
+		"organizationId":      resource.NewStringProperty("ORGANIZATION_ID"),
+		"name":                resource.NewStringProperty("helpdesk"),
+		"type":                resource.NewStringProperty("READ_ONLY"),
+		"rules":               resource.NewArrayProperty([]resource.PropertyValue{rule("ALLOW", "USER_ID")}),
+		"impersonationRoleId": resource.NewStringProperty("ROLE_ID"),
+	}
+
+	Convey("When changing the rules of an impersonation role", t, func() {
+		news := olds.Copy()
+		delete(news, "impersonationRoleId")
+		news["type"] = resource.NewStringProperty("FULL_ACCESS")
+		news["rules"] = resource.NewArrayProperty([]resource.PropertyValue{rule("DENY", "ADMIN_ID"), rule("ALLOW", "USER_ID")})
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("ImpersonationRole"), ID: "ROLE_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeTrue)
+		So(diff.DetailedDiff["type"].Kind, ShouldEqual, p.Update)
+		So(diff.DetailedDiff["rules"].Kind, ShouldEqual, p.Update)
+	})
+
+	Convey("When moving an impersonation role to another organization", t, func() {
+		news := olds.Copy()
+		delete(news, "impersonationRoleId")
+		news["organizationId"] = resource.NewStringProperty("OTHER_ORGANIZATION_ID")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("ImpersonationRole"), ID: "ROLE_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.DetailedDiff["organizationId"].Kind, ShouldEqual, p.UpdateReplace)
+	})
+}
+
+func TestRetentionPolicyValidation(t *testing.T) {
+	prov := provider()
+
+	folder := func(name, action string, period float64) resource.PropertyValue {
+		configuration := resource.PropertyMap{
+			"name":   resource.NewStringProperty(name),
+			"action": resource.NewStringProperty(action),
+		}
+		if period > 0 {
+			configuration["period"] = resource.NewNumberProperty(period)
+		}
+		return resource.NewObjectProperty(configuration)
+	}
+	check := func(folders ...resource.PropertyValue) []p.CheckFailure {
+		response, err := prov.Check(p.CheckRequest{
+			Urn: urn("RetentionPolicy"),
+			News: resource.PropertyMap{
+				"region":               resource.NewStringProperty("eu-west-1"),
+				"organizationId":       resource.NewStringProperty("ORGANIZATION_ID"),
+				"name":                 resource.NewStringProperty("legal"),
+				"folderConfigurations": resource.NewArrayProperty(folders),
+			},
+		})
+		So(err, ShouldBeNil)
+		return response.Failures
+	}
+
+	Convey("When configuring a valid retention policy", t, func() {
+		failures := check(folder("DELETED_ITEMS", "PERMANENTLY_DELETE", 30), folder("INBOX", "NONE", 0))
+
+		So(failures, ShouldBeEmpty)
+	})
+
+	Convey("When setting a period for action NONE", t, func() {
+		failures := check(folder("INBOX", "NONE", 30))
+
+		So(failures, ShouldHaveLength, 1)
+		So(failures[0].Property, ShouldEqual, "folderConfigurations")
+	})
+
+	Convey("When omitting the period for action DELETE", t, func() {
+		failures := check(folder("JUNK_EMAIL", "DELETE", 0))
+
+		So(failures, ShouldHaveLength, 1)
+		So(failures[0].Property, ShouldEqual, "folderConfigurations")
+	})
+
+	Convey("When configuring a folder twice", t, func() {
+		failures := check(folder("DRAFTS", "DELETE", 10), folder("DRAFTS", "DELETE", 20))
+
+		So(failures, ShouldHaveLength, 1)
+		So(failures[0].Property, ShouldEqual, "folderConfigurations")
+	})
+}
+
+func TestAuditLogConfigurationDestinations(t *testing.T) {
+	prov := provider()
+
+	check := func(destination string) []p.CheckFailure {
+		response, err := prov.Check(p.CheckRequest{
+			Urn: urn("AuditLogConfiguration"),
+			News: resource.PropertyMap{
+				"region":                          resource.NewStringProperty("eu-west-1"),
+				"organizationId":                  resource.NewStringProperty("ORGANIZATION_ID"),
+				"mailboxAccessLogsDestinationArn": resource.NewStringProperty(destination),
+			},
+		})
+		So(err, ShouldBeNil)
+		return response.Failures
+	}
+
+	Convey("When delivering audit logs to supported destinations", t, func() {
+		So(check("arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/audit"), ShouldBeEmpty)
+		So(check("arn:aws:s3:::workmail-audit-logs"), ShouldBeEmpty)
+		So(check("arn:aws:firehose:eu-west-1:123456789012:deliverystream/workmail-audit"), ShouldBeEmpty)
+	})
+
+	Convey("When delivering audit logs to an unsupported destination", t, func() {
+		for _, destination := range []string{"arn:aws:sqs:eu-west-1:123456789012:workmail-audit", "workmail-audit-logs"} {
+			failures := check(destination)
+			So(failures, ShouldHaveLength, 1)
+			So(failures[0].Property, ShouldEqual, "mailboxAccessLogsDestinationArn")
+		}
+	})
+}
+
+func TestAvailabilityConfigurationSecretPassword(t *testing.T) {
+	prov := provider()
+
+	Convey("When configuring an EWS availability provider", t, func() {
+		availability, err := prov.Create(p.CreateRequest{
+			Urn: urn("AvailabilityConfiguration"),
+			Properties: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+				"domainName":     resource.NewStringProperty("exchange.gothub.io"),
+				"ewsEndpoint":    resource.NewStringProperty("https://exchange.gothub.io/EWS/Exchange.asmx"),
+				"ewsUsername":    resource.NewStringProperty("availability"),
+				"ewsPassword":    resource.MakeSecret(resource.NewStringProperty("test-password-1234")),
+			},
+			Preview: true,
+		})
+
+		So(err, ShouldBeNil)
+		So(availability.Properties["ewsPassword"].ContainsSecrets(), ShouldBeTrue)
+	})
+}
+
+func TestAvailabilityConfigurationProviderCheck(t *testing.T) {
+	prov := provider()
+
+	check := func(providerInputs resource.PropertyMap) map[string]string {
+		inputs := resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"domainName":     resource.NewStringProperty("exchange.gothub.io"),
+		}
+		for key, value := range providerInputs {
+			inputs[key] = value
+		}
+		response, err := prov.Check(p.CheckRequest{Urn: urn("AvailabilityConfiguration"), News: inputs})
+		So(err, ShouldBeNil)
+		failures := map[string]string{}
+		for _, failure := range response.Failures {
+			failures[string(failure.Property)] = failure.Reason
+		}
+		return failures
+	}
+
+	ews := resource.PropertyMap{
+		"ewsEndpoint": resource.NewStringProperty("https://exchange.gothub.io/EWS/Exchange.asmx"),
+		"ewsUsername": resource.NewStringProperty("availability"),
+		"ewsPassword": resource.MakeSecret(resource.NewStringProperty("test-password-1234")),
+	}
+	lambdaArn := resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:availability")
+
+	Convey("When configuring a single availability provider", t, func() {
+		So(check(ews), ShouldBeEmpty)
+		So(check(resource.PropertyMap{"lambdaArn": lambdaArn}), ShouldBeEmpty)
+
+		unknown := ews.Copy()
+		unknown["ewsPassword"] = resource.MakeComputed(resource.NewStringProperty(""))
+		So(check(unknown), ShouldBeEmpty)
+	})
+
+	Convey("When configuring both availability providers", t, func() {
+		both := ews.Copy()
+		both["lambdaArn"] = lambdaArn
+		failures := check(both)
+
+		So(failures, ShouldHaveLength, 1)
+		So(failures["lambdaArn"], ShouldContainSubstring, "either the EWS provider")
+	})
+
+	Convey("When configuring no availability provider", t, func() {
+		So(check(resource.PropertyMap{})["ewsEndpoint"], ShouldContainSubstring, "either the EWS provider")
+	})
+
+	Convey("When configuring an incomplete EWS provider", t, func() {
+		incomplete := ews.Copy()
+		delete(incomplete, "ewsPassword")
+		failures := check(incomplete)
+
+		So(failures, ShouldHaveLength, 1)
+		So(failures["ewsEndpoint"], ShouldContainSubstring, "must all be specified")
+	})
+}
+
+func TestMailboxExportJobDiff(t *testing.T) {
+	prov := provider()
+
+	Convey("When changing the export destination of a mailbox export job", t, func() {
+		olds := resource.PropertyMap{
+			"region":            resource.NewStringProperty("eu-west-1"),
+			"organizationId":    resource.NewStringProperty("ORGANIZATION_ID"),
+			"entityId":          resource.NewStringProperty("USER_ID"),
+			"s3BucketName":      resource.NewStringProperty("mailbox-archive"),
+			"s3Prefix":          resource.NewStringProperty("departed/"),
+			"kmsKeyArn":         resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/KEY_ID"),
+			"roleArn":           resource.NewStringProperty("arn:aws:iam::123456789012:role/workmail-export"),
+			"jobId":             resource.NewStringProperty("JOB_ID"),
+			"state":             resource.NewStringProperty("COMPLETED"),
+			"estimatedProgress": resource.NewNumberProperty(100),
+		}
+		news := olds.Copy()
+		for _, output := range []resource.PropertyKey{"jobId", "state", "estimatedProgress"} {
+			delete(news, output)
+		}
+		news["s3Prefix"] = resource.NewStringProperty("archive/")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("MailboxExportJob"), ID: "JOB_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.DetailedDiff, ShouldHaveLength, 1)
+		So(diff.DetailedDiff["s3Prefix"].Kind, ShouldEqual, p.UpdateReplace)
+	})
+}
+
+func TestCognitoEmailSenderDiff(t *testing.T) {
+	prov := provider()
+
+	olds := resource.PropertyMap{
+		"userPoolId": resource.NewStringProperty("eu-west-1_POOL"),
+		"lambdaArn":  resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:send-email"),
+		"kmsKeyArn":  resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/KEY_ID"),
+	}
+
+	Convey("When nothing changed on a cognito email sender", t, func() {
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("CognitoEmailSender"), ID: "SENDER", Olds: olds, News: olds.Copy()})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeFalse)
+	})
+
+	Convey("When switching the lambda of a cognito email sender", t, func() {
+		news := olds.Copy()
+		news["lambdaArn"] = resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:send-email-v2")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("CognitoEmailSender"), ID: "SENDER", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeTrue)
+		So(diff.DetailedDiff, ShouldHaveLength, 1)
+		So(diff.DetailedDiff["lambdaArn"].Kind, ShouldEqual, p.Update)
+	})
+
+	Convey("When reordering the auto-verified attributes of a cognito email sender", t, func() {
+		olds := olds.Copy()
+		olds["autoVerifiedAttributes"] = resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("email"), resource.NewStringProperty("phone_number"),
+		})
+		news := olds.Copy()
+		news["autoVerifiedAttributes"] = resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("phone_number"), resource.NewStringProperty("email"),
+		})
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("CognitoEmailSender"), ID: "SENDER", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(diff.HasChanges, ShouldBeFalse)
+	})
+}
+
+func TestLoadAWSConfigInjection(t *testing.T) {
+	prov := provider()
+
+	loadAWSConfig := awsworkmail.LoadAWSConfig
+	defer func() { awsworkmail.LoadAWSConfig = loadAWSConfig }()
+	awsworkmail.LoadAWSConfig = func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+		options := config.LoadOptions{}
+		for _, optFn := range optFns {
+			So(optFn(&options), ShouldBeNil)
+		}
+		return aws.Config{}, fmt.Errorf("no credentials for region %s and profile %s", options.Region, options.SharedConfigProfile)
+	}
+
+	Convey("When invoking a function on a configured provider", t, func() {
+		err := prov.Configure(p.ConfigureRequest{
+			Args: resource.PropertyMap{
+				"region":      resource.NewStringProperty("eu-central-1"),
+				"profile":     resource.NewStringProperty("workmail"),
+				"maxAttempts": resource.NewNumberProperty(3),
+			},
+		})
+		So(err, ShouldBeNil)
+
+		_, err = prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:getOrganization",
+			Args: resource.PropertyMap{
+				"region": resource.NewStringProperty(""),
+				"domain": resource.NewStringProperty("dev.gothub.io"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "no credentials for region eu-central-1 and profile workmail")
+	})
+}
+
+func TestCheckInputs(t *testing.T) {
+	prov := provider()
+
+	check := func(typ string, inputs resource.PropertyMap) map[string]string {
+		response, err := prov.Check(p.CheckRequest{Urn: urn(typ), News: inputs})
+		So(err, ShouldBeNil)
+		failures := map[string]string{}
+		for _, failure := range response.Failures {
+			failures[failure.Property] = failure.Reason
+		}
+		return failures
+	}
+
+	Convey("When checking an organization with an invalid region and alias", t, func() {
+		failures := check("Organization", resource.PropertyMap{
+			"region":    resource.NewStringProperty("europe"),
+			"alias":     resource.NewStringProperty("d-gothub"),
+			"kmsKeyArn": resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:alias/workmail"),
+		})
+
+		So(failures, ShouldHaveLength, 3)
+		So(failures["region"], ShouldContainSubstring, "europe")
+		So(failures["alias"], ShouldContainSubstring, "d-")
+		So(failures["kmsKeyArn"], ShouldContainSubstring, "KMS key")
+	})
+
+	Convey("When checking a user without organizationId or domain", t, func() {
+		failures := check("User", resource.PropertyMap{
+			"region":      resource.NewStringProperty("eu-west-1"),
+			"displayName": resource.NewStringProperty("Info"),
+			"name":        resource.NewStringProperty(strings.Repeat("a", 65)),
+		})
+
+		So(failures, ShouldHaveLength, 2)
+		So(failures["organizationId"], ShouldContainSubstring, "domain")
+		So(failures["name"], ShouldContainSubstring, "64")
+	})
+
+	Convey("When checking a user whose organization is not known yet", t, func() {
+		failures := check("User", resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"displayName":    resource.NewStringProperty("Info"),
+			"name":           resource.NewStringProperty("info"),
+			"organizationId": resource.MakeComputed(resource.NewStringProperty("")),
+		})
+
+		So(failures, ShouldBeEmpty)
+	})
+
+	Convey("When checking an invalid email prefix and cognito inputs", t, func() {
+		So(check("WorkmailRegistration", resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"entityId":       resource.NewStringProperty("ENTITY_ID"),
+			"emailPrefix":    resource.NewStringProperty("info@dev.gothub.io"),
+		}), ShouldContainKey, "emailPrefix")
+
+		failures := check("CognitoEmailSender", resource.PropertyMap{
+			"userPoolId": resource.NewStringProperty("POOL"),
+			"lambdaArn":  resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:send-email"),
+			"kmsKeyArn":  resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:send-email"),
+		})
+		So(failures, ShouldHaveLength, 2)
+		So(failures, ShouldContainKey, "userPoolId")
+		So(failures, ShouldContainKey, "kmsKeyArn")
+	})
+
+	Convey("When checking an access control rule with an invalid IP range", t, func() {
+		failures := check("AccessControlRule", resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"name":           resource.NewStringProperty("office-only"),
+			"description":    resource.NewStringProperty("Only allow IMAP from the office"),
+			"effect":         resource.NewStringProperty("ALLOW"),
+			"notIpRanges": resource.NewArrayProperty([]resource.PropertyValue{
+				resource.NewStringProperty("10.0.0.300/24"),
+			}),
+		})
+
+		So(failures, ShouldHaveLength, 1)
+		So(failures["notIpRanges"], ShouldContainSubstring, "10.0.0.300/24")
+	})
+}
+
+func TestUserWriteOnlyPassword(t *testing.T) {
+	prov := provider()
+
+	resets := []string{}
+	defer stubAWSRequests(prov, func(target string, body []byte) (int, string) {
+		switch target {
+		case "WorkMailService.DescribeIdentityProviderConfiguration":
+			return http.StatusBadRequest, `{"__type":"ResourceNotFoundException","message":"No identity provider configuration"}`
+		case "WorkMailService.CreateUser":
+			return http.StatusOK, `{"UserId":"USER_ID"}`
+		case "WorkMailService.ResetPassword":
+			resets = append(resets, string(body))
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	inputs := resource.PropertyMap{
+		"region":            resource.NewStringProperty("eu-west-1"),
+		"organizationId":    resource.NewStringProperty("ORGANIZATION_ID"),
+		"displayName":       resource.NewStringProperty("Info"),
+		"name":              resource.NewStringProperty("info"),
+		"password":          resource.MakeSecret(resource.NewStringProperty("Correct-Horse-1")),
+		"passwordWriteOnly": resource.NewBoolProperty(true),
+	}
+
+	Convey("When creating a user with a write-only password", t, func() {
+		response, err := prov.Create(p.CreateRequest{Urn: urn("User"), Properties: inputs})
+
+		So(err, ShouldBeNil)
+		So(response.Properties, ShouldNotContainKey, "password")
+		So(response.Properties["passwordHash"].ContainsSecrets(), ShouldBeTrue)
+		So(response.Properties["passwordHash"].SecretValue().Element.StringValue(), ShouldNotContainSubstring, "Correct-Horse-1")
+
+		Convey("When the password is unchanged", func() {
+			diff, err := prov.Diff(p.DiffRequest{Urn: urn("User"), ID: "USER_ID", Olds: response.Properties, News: inputs.Copy()})
+
+			So(err, ShouldBeNil)
+			So(diff.HasChanges, ShouldBeFalse)
+		})
+
+		Convey("When the password changes", func() {
+			news := inputs.Copy()
+			news["password"] = resource.MakeSecret(resource.NewStringProperty("Battery-Staple-2"))
+			diff, err := prov.Diff(p.DiffRequest{Urn: urn("User"), ID: "USER_ID", Olds: response.Properties, News: news})
+
+			So(err, ShouldBeNil)
+			So(diff.HasChanges, ShouldBeTrue)
+			So(diff.DetailedDiff["password"].Kind, ShouldEqual, p.Update)
+			So(diff.DeleteBeforeReplace, ShouldBeTrue)
+
+			Convey("When the password is reset", func() {
+				resets = nil
+				update, err := prov.Update(p.UpdateRequest{Urn: urn("User"), ID: "USER_ID", Olds: response.Properties, News: news})
+
+				So(err, ShouldBeNil)
+				So(resets, ShouldResemble, []string{`{"OrganizationId":"ORGANIZATION_ID","Password":"Battery-Staple-2","UserId":"USER_ID"}`})
+				So(update.Properties, ShouldNotContainKey, "password")
+				So(update.Properties["passwordHash"].SecretValue().Element.StringValue(), ShouldStartWith, "$argon2id$v=19$m=7168,t=5,p=1$")
+				So(update.Properties["passwordHash"], ShouldNotResemble, response.Properties["passwordHash"])
+
+				Convey("When the new password is unchanged", func() {
+					diff, err := prov.Diff(p.DiffRequest{Urn: urn("User"), ID: "USER_ID", Olds: update.Properties, News: news.Copy()})
+
+					So(err, ShouldBeNil)
+					So(diff.HasChanges, ShouldBeFalse)
+				})
+			})
+		})
+
+		Convey("When passwordWriteOnly is toggled without changing the password", func() {
+			news := inputs.Copy()
+			news["passwordWriteOnly"] = resource.NewBoolProperty(false)
+			resets = nil
+			_, err := prov.Update(p.UpdateRequest{Urn: urn("User"), ID: "USER_ID", Olds: response.Properties, News: news})
+
+			So(err, ShouldBeNil)
+			So(resets, ShouldBeEmpty)
+		})
+	})
+
+	Convey("When creating a user with a stored password", t, func() {
+		news := inputs.Copy()
+		delete(news, "passwordWriteOnly")
+		news["password"] = resource.NewStringProperty("Correct-Horse-1")
+		response, err := prov.Create(p.CreateRequest{Urn: urn("User"), Properties: news})
+
+		So(err, ShouldBeNil)
+		So(response.Properties["password"].ContainsSecrets(), ShouldBeTrue)
+		So(response.Properties, ShouldNotContainKey, "passwordHash")
+	})
+}
+
+func TestUserMailboxQuotaAfterRegistration(t *testing.T) {
+	prov := provider()
+
+	registered := false
+	requests := []string{}
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests = append(requests, target)
+		switch target {
+		case "WorkMailService.CreateUser":
+			return http.StatusOK, `{"UserId":"USER_ID"}`
+		case "WorkMailService.DescribeUser":
+			return http.StatusOK, `{"UserId":"USER_ID","Name":"info","DisplayName":"Info","State":"ENABLED"}`
+		case "WorkMailService.DescribeOrganization":
+			return http.StatusOK, `{"OrganizationId":"ORGANIZATION_ID","State":"Active","DefaultMailDomain":"dev.gothub.io"}`
+		case "WorkMailService.RegisterToWorkMail":
+			registered = true
+			return http.StatusOK, `{}`
+		case "WorkMailService.UpdateMailboxQuota":
+			if !registered {
+				return http.StatusBadRequest, `{"__type":"EntityStateException","message":"User is not registered"}`
+			}
+			return http.StatusOK, `{}`
+		case "WorkMailService.GetMailboxDetails":
+			return http.StatusOK, `{"MailboxQuota":2048,"MailboxSize":12.5}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When creating a user and registering it with a mailbox quota", t, func() {
+		inputs := resource.PropertyMap{
+			"region":         resource.NewStringProperty("eu-west-1"),
+			"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+			"displayName":    resource.NewStringProperty("Info"),
+			"name":           resource.NewStringProperty("info"),
+		}
+		user, err := prov.Create(p.CreateRequest{Urn: urn("User"), Properties: inputs})
+		So(err, ShouldBeNil)
+
+		_, err = prov.Create(p.CreateRequest{
+			Urn: urn("WorkmailRegistration"),
+			Properties: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+				"entityId":       resource.NewStringProperty("USER_ID"),
+				"emailPrefix":    resource.NewStringProperty("info"),
+				"mailboxQuotaMb": resource.NewNumberProperty(2048),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		So(requests[len(requests)-2:], ShouldResemble, []string{
+			"WorkMailService.RegisterToWorkMail",
+			"WorkMailService.UpdateMailboxQuota",
+		})
+
+		Convey("When refreshing and updating the user", func() {
+			read, err := prov.Read(p.ReadRequest{Urn: urn("User"), ID: user.ID, Properties: user.Properties, Inputs: inputs})
+			So(err, ShouldBeNil)
+			So(read.Properties["currentMailboxQuotaMb"].NumberValue(), ShouldEqual, 2048)
+
+			news := inputs.Copy()
+			news["passwordWriteOnly"] = resource.NewBoolProperty(true)
+			update, err := prov.Update(p.UpdateRequest{Urn: urn("User"), ID: user.ID, Olds: read.Properties, News: news})
+
+			So(err, ShouldBeNil)
+			So(update.Properties["currentMailboxQuotaMb"].NumberValue(), ShouldEqual, 2048)
+			So(update.Properties["currentMailboxSizeMb"].NumberValue(), ShouldEqual, 12.5)
+		})
+	})
+}
+
+func TestEmailMonitoringConfiguration(t *testing.T) {
+	prov := provider()
+
+	configured := false
+	defer stubAWS(prov, func(target string) (int, string) {
+		switch target {
+		case "WorkMailService.PutEmailMonitoringConfiguration":
+			configured = true
+			return http.StatusOK, `{}`
+		case "WorkMailService.DescribeEmailMonitoringConfiguration":
+			if !configured {
+				return http.StatusBadRequest, `{"__type":"ResourceNotFoundException","message":"No email monitoring configuration"}`
+			}
+			return http.StatusOK, `{"RoleArn":"arn:aws:iam::123456789012:role/workmail-monitoring","LogGroupArn":"arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/archive"}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When creating an email monitoring configuration", t, func() {
+		response, err := prov.Create(p.CreateRequest{
+			Urn: urn("EmailMonitoringConfiguration"),
+			Properties: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+				"roleArn":        resource.NewStringProperty("arn:aws:iam::123456789012:role/workmail-monitoring"),
+				"logGroupArn":    resource.NewStringProperty("arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/email"),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		So(configured, ShouldBeTrue)
+		So(response.ID, ShouldEqual, "ORGANIZATION_ID")
+
+		Convey("When the log group was changed outside of pulumi", func() {
+			read, err := prov.Read(p.ReadRequest{Urn: urn("EmailMonitoringConfiguration"), ID: response.ID, Properties: response.Properties})
+
+			So(err, ShouldBeNil)
+			So(read.ID, ShouldEqual, "ORGANIZATION_ID")
+			So(read.Properties["logGroupArn"].StringValue(), ShouldEqual, "arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/archive")
+			So(read.Inputs["logGroupArn"].StringValue(), ShouldEqual, "arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/archive")
+		})
+
+		Convey("When the configuration was deleted outside of pulumi", func() {
+			configured = false
+			read, err := prov.Read(p.ReadRequest{Urn: urn("EmailMonitoringConfiguration"), ID: response.ID, Properties: response.Properties})
+
+			So(err, ShouldBeNil)
+			So(read.ID, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestAuditLogConfigurationPartialFailure(t *testing.T) {
+	prov := provider()
+
+	deliveries := 0
+	requests := []string{}
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests = append(requests, target)
+		switch target {
+		case "WorkMailService.DescribeOrganization":
+			return http.StatusOK, `{"OrganizationId":"ORGANIZATION_ID","ARN":"arn:aws:workmail:eu-west-1:123456789012:organization/ORGANIZATION_ID"}`
+		case "Logs_20140328.PutDeliverySource":
+			return http.StatusOK, `{}`
+		case "Logs_20140328.PutDeliveryDestination":
+			return http.StatusOK, `{"deliveryDestination":{"arn":"arn:aws:logs:eu-west-1:123456789012:delivery-destination:DESTINATION"}}`
+		case "Logs_20140328.CreateDelivery":
+			deliveries++
+			if deliveries > 1 {
+				return http.StatusBadRequest, `{"__type":"ServiceQuotaExceededException","message":"Delivery limit reached"}`
+			}
+			return http.StatusOK, `{"delivery":{"id":"DELIVERY_ID"}}`
+		case "Logs_20140328.DeleteDeliveryDestination", "Logs_20140328.DeleteDeliverySource":
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When enabling the second audit log fails", t, func() {
+		response, err := prov.Create(p.CreateRequest{
+			Urn: urn("AuditLogConfiguration"),
+			Properties: resource.PropertyMap{
+				"region":                           resource.NewStringProperty("eu-west-1"),
+				"organizationId":                   resource.NewStringProperty("ORGANIZATION_ID"),
+				"accessControlLogsDestinationArn":  resource.NewStringProperty("arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/access-control"),
+				"authenticationLogsDestinationArn": resource.NewStringProperty("arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/authentication"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(response.PartialState, ShouldNotBeNil)
+		So(response.PartialState.Reasons[0], ShouldContainSubstring, "Delivery limit reached")
+		So(response.ID, ShouldEqual, "ORGANIZATION_ID")
+		So(response.Properties["deliveries"].ArrayValue(), ShouldHaveLength, 1)
+		So(response.Properties["accessControlLogsDestinationArn"].StringValue(), ShouldEqual, "arn:aws:logs:eu-west-1:123456789012:log-group:/workmail/access-control")
+		So(response.Properties["authenticationLogsDestinationArn"].IsNull(), ShouldBeTrue)
+		So(requests[len(requests)-2:], ShouldResemble, []string{
+			"Logs_20140328.DeleteDeliveryDestination",
+			"Logs_20140328.DeleteDeliverySource",
+		})
+	})
+}
+
+func TestIdentityProviderConfigurationRollback(t *testing.T) {
+	prov := provider()
+
+	requests := []string{}
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests = append(requests, target)
+		switch target {
+		case "WorkMailService.CreateIdentityCenterApplication":
+			return http.StatusOK, `{"ApplicationArn":"arn:aws:sso::123456789012:application/ssoins-1234567890abcdef/apl-1234567890abcdef"}`
+		case "WorkMailService.PutIdentityProviderConfiguration":
+			return http.StatusBadRequest, `{"__type":"InvalidParameterException","message":"Instance is not in the same region"}`
+		case "WorkMailService.DeleteIdentityCenterApplication":
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When the configuration of a created application fails", t, func() {
+		_, err := prov.Create(p.CreateRequest{
+			Urn: urn("IdentityProviderConfiguration"),
+			Properties: resource.PropertyMap{
+				"region":                    resource.NewStringProperty("eu-west-1"),
+				"organizationId":            resource.NewStringProperty("ORGANIZATION_ID"),
+				"authenticationMode":        resource.NewStringProperty("IDENTITY_PROVIDER_AND_DIRECTORY"),
+				"instanceArn":               resource.NewStringProperty("arn:aws:sso:::instance/ssoins-1234567890abcdef"),
+				"personalAccessTokenStatus": resource.NewStringProperty("INACTIVE"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "Instance is not in the same region")
+		So(requests[len(requests)-1], ShouldEqual, "WorkMailService.DeleteIdentityCenterApplication")
+	})
+}
+
+func TestUserAuthenticationMode(t *testing.T) {
+	prov := provider()
+
+	requests := []string{}
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests = append(requests, target)
+		switch target {
+		case "WorkMailService.DescribeIdentityProviderConfiguration":
+			return http.StatusOK, `{"AuthenticationMode":"IDENTITY_PROVIDER_ONLY"}`
+		case "WorkMailService.CreateUser":
+			return http.StatusOK, `{"UserId":"USER_ID"}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	inputs := resource.PropertyMap{
+		"region":         resource.NewStringProperty("eu-west-1"),
+		"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+		"displayName":    resource.NewStringProperty("Info"),
+		"name":           resource.NewStringProperty("info"),
+	}
+
+	Convey("When creating a user without a password", t, func() {
+		_, err := prov.Create(p.CreateRequest{Urn: urn("User"), Properties: inputs})
+
+		So(err, ShouldBeNil)
+		So(requests, ShouldResemble, []string{"WorkMailService.CreateUser"})
+	})
+
+	Convey("When creating a user with a password in an Identity Center only organization", t, func() {
+		news := inputs.Copy()
+		news["password"] = resource.MakeSecret(resource.NewStringProperty("Correct-Horse-1"))
+		_, err := prov.Create(p.CreateRequest{Urn: urn("User"), Properties: news})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "only allows signing in with IAM Identity Center")
+	})
+
+	Convey("When updating a user mapped to an Identity Center user", t, func() {
+		news := inputs.Copy()
+		news["identityProviderUserId"] = resource.NewStringProperty("IDENTITY_CENTER_USER_ID")
+		olds := news.Copy()
+		olds["userId"] = resource.NewStringProperty("USER_ID")
+		olds["identityProviderIdentityStoreId"] = resource.NewStringProperty("d-1234567890")
+		news["passwordWriteOnly"] = resource.NewBoolProperty(true)
+		update, err := prov.Update(p.UpdateRequest{Urn: urn("User"), ID: "USER_ID", Olds: olds, News: news})
+
+		So(err, ShouldBeNil)
+		So(update.Properties["identityProviderIdentityStoreId"].StringValue(), ShouldEqual, "d-1234567890")
+	})
+}
+
+func TestMailboxExportJobCancelledOnFailure(t *testing.T) {
+	prov := provider()
+
+	requests := map[string]int{}
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests[target]++
+		switch target {
+		case "WorkMailService.GetMailboxDetails":
+			return http.StatusOK, `{"MailboxQuota":51200,"MailboxSize":12.5}`
+		case "WorkMailService.StartMailboxExportJob":
+			return http.StatusOK, `{"JobId":"JOB_ID"}`
+		case "WorkMailService.DescribeMailboxExportJob":
+			return http.StatusBadRequest, `{"__type":"AccessDeniedException","message":"Not allowed to describe the job"}`
+		case "WorkMailService.CancelMailboxExportJob":
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When the export job cannot be followed", t, func() {
+		_, err := prov.Create(p.CreateRequest{
+			Urn: urn("MailboxExportJob"),
+			Properties: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+				"entityId":       resource.NewStringProperty("USER_ID"),
+				"s3BucketName":   resource.NewStringProperty("mailbox-archive"),
+				"s3Prefix":       resource.NewStringProperty("info"),
+				"kmsKeyArn":      resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+				"roleArn":        resource.NewStringProperty("arn:aws:iam::123456789012:role/workmail-export"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "Not allowed to describe the job")
+		So(requests["WorkMailService.CancelMailboxExportJob"], ShouldEqual, 1)
+	})
+}
+
+func TestMailboxExportJobExpired(t *testing.T) {
+	prov := provider()
+
+	defer stubAWS(prov, func(target string) (int, string) {
+		if target == "WorkMailService.DescribeMailboxExportJob" {
+			return http.StatusBadRequest, `{"__type":"EntityNotFoundException","message":"Job not found"}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When a completed export job has expired", t, func() {
+		state := resource.PropertyMap{
+			"region":            resource.NewStringProperty("eu-west-1"),
+			"organizationId":    resource.NewStringProperty("ORGANIZATION_ID"),
+			"entityId":          resource.NewStringProperty("USER_ID"),
+			"s3BucketName":      resource.NewStringProperty("mailbox-archive"),
+			"s3Prefix":          resource.NewStringProperty("info"),
+			"kmsKeyArn":         resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+			"roleArn":           resource.NewStringProperty("arn:aws:iam::123456789012:role/workmail-export"),
+			"jobId":             resource.NewStringProperty("JOB_ID"),
+			"state":             resource.NewStringProperty("COMPLETED"),
+			"estimatedProgress": resource.NewNumberProperty(100),
+			"s3Path":            resource.NewStringProperty("s3://mailbox-archive/info/JOB_ID.zip"),
+		}
+		read, err := prov.Read(p.ReadRequest{Urn: urn("MailboxExportJob"), ID: "JOB_ID", Properties: state})
+
+		So(err, ShouldBeNil)
+		So(read.ID, ShouldEqual, "JOB_ID")
+		So(read.Properties["state"].StringValue(), ShouldEqual, "COMPLETED")
+		So(read.Properties["s3Path"].StringValue(), ShouldEqual, "s3://mailbox-archive/info/JOB_ID.zip")
+	})
+}
+
+func TestCognitoEmailSenderUserPoolUpdate(t *testing.T) {
+	prov := provider()
+
+	var update map[string]any
+	defer stubAWSRequests(prov, func(target string, body []byte) (int, string) {
+		switch target {
+		case "AWSCognitoIdentityProviderService.DescribeUserPool":
+			return http.StatusOK, `{"UserPool":{
+				"Id":"eu-west-1_AbCdEfGhI",
+				"Name":"gothub",
+				"Arn":"arn:aws:cognito-idp:eu-west-1:123456789012:userpool/eu-west-1_AbCdEfGhI",
+				"CreationDate":1.7139e9,
+				"LastModifiedDate":1.7139e9,
+				"Policies":{"PasswordPolicy":{"MinimumLength":8,"RequireUppercase":true,"RequireLowercase":true,"RequireNumbers":true,"RequireSymbols":false,"TemporaryPasswordValidityDays":7}},
+				"DeletionProtection":"ACTIVE",
+				"LambdaConfig":{},
+				"SchemaAttributes":[{"Name":"email","AttributeDataType":"String","Mutable":true,"Required":true,"StringAttributeConstraints":{"MinLength":"0","MaxLength":"2048"}}],
+				"AutoVerifiedAttributes":["email"],
+				"UsernameAttributes":["email"],
+				"VerificationMessageTemplate":{"DefaultEmailOption":"CONFIRM_WITH_CODE"},
+				"UserAttributeUpdateSettings":{"AttributesRequireVerificationBeforeUpdate":["email"]},
+				"MfaConfiguration":"OFF",
+				"EstimatedNumberOfUsers":42,
+				"EmailConfiguration":{"EmailSendingAccount":"COGNITO_DEFAULT"},
+				"AdminCreateUserConfig":{"AllowAdminCreateUserOnly":true,"UnusedAccountValidityDays":7},
+				"UsernameConfiguration":{"CaseSensitive":false},
+				"AccountRecoverySetting":{"RecoveryMechanisms":[{"Priority":1,"Name":"verified_email"}]}
+			}}`
+		case "AWSCognitoIdentityProviderService.UpdateUserPool":
+			update = map[string]any{}
+			if err := json.Unmarshal(body, &update); err != nil {
+				return http.StatusBadRequest, `{"__type":"InvalidParameterException","message":"Invalid request body"}`
+			}
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When adding a custom email sender to a user pool", t, func() {
+		_, err := prov.Create(p.CreateRequest{
+			Urn: urn("CognitoEmailSender"),
+			Properties: resource.PropertyMap{
+				"region":     resource.NewStringProperty("eu-west-1"),
+				"userPoolId": resource.NewStringProperty("eu-west-1_AbCdEfGhI"),
+				"lambdaArn":  resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:email-sender"),
+				"kmsKeyArn":  resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		var expected map[string]any
+		So(json.Unmarshal([]byte(`{
+			"UserPoolId":"eu-west-1_AbCdEfGhI",
+			"AccountRecoverySetting":{"RecoveryMechanisms":[{"Name":"verified_email","Priority":1}]},
+			"AdminCreateUserConfig":{"AllowAdminCreateUserOnly":true},
+			"AutoVerifiedAttributes":["email"],
+			"DeletionProtection":"ACTIVE",
+			"EmailConfiguration":{"EmailSendingAccount":"COGNITO_DEFAULT"},
+			"LambdaConfig":{
+				"CustomEmailSender":{"LambdaArn":"arn:aws:lambda:eu-west-1:123456789012:function:email-sender","LambdaVersion":"V1_0"},
+				"KMSKeyID":"arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+			},
+			"MfaConfiguration":"OFF",
+			"Policies":{"PasswordPolicy":{"MinimumLength":8,"RequireUppercase":true,"RequireLowercase":true,"RequireNumbers":true,"TemporaryPasswordValidityDays":7}},
+			"UserAttributeUpdateSettings":{"AttributesRequireVerificationBeforeUpdate":["email"]},
+			"VerificationMessageTemplate":{"DefaultEmailOption":"CONFIRM_WITH_CODE"}
+		}`), &expected), ShouldBeNil)
+		So(update, ShouldResemble, expected)
+	})
+}
+
+func TestCognitoEmailSenderRestoresAutoVerifiedAttributes(t *testing.T) {
+	prov := provider()
+
+	var autoVerifiedAttributes []string
+	defer stubAWSRequests(prov, func(target string, body []byte) (int, string) {
+		switch target {
+		case "AWSCognitoIdentityProviderService.DescribeUserPool":
+			return http.StatusOK, `{"UserPool":{"Id":"eu-west-1_AbCdEfGhI","AutoVerifiedAttributes":["email"],"UsernameAttributes":["email"]}}`
+		case "AWSCognitoIdentityProviderService.UpdateUserPool":
+			var update struct{ AutoVerifiedAttributes []string }
+			if err := json.Unmarshal(body, &update); err != nil {
+				return http.StatusBadRequest, `{"__type":"InvalidParameterException","message":"Invalid request body"}`
+			}
+			autoVerifiedAttributes = update.AutoVerifiedAttributes
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	inputs := resource.PropertyMap{
+		"region":     resource.NewStringProperty("eu-west-1"),
+		"userPoolId": resource.NewStringProperty("eu-west-1_AbCdEfGhI"),
+		"lambdaArn":  resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:email-sender"),
+		"kmsKeyArn":  resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+		"autoVerifiedAttributes": resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewStringProperty("email"),
+			resource.NewStringProperty("phone_number"),
+		}),
+	}
+
+	Convey("When autoVerifiedAttributes is no longer managed", t, func() {
+		created, err := prov.Create(p.CreateRequest{Urn: urn("CognitoEmailSender"), Properties: inputs})
+		So(err, ShouldBeNil)
+		So(autoVerifiedAttributes, ShouldResemble, []string{"email", "phone_number"})
+
+		news := inputs.Copy()
+		delete(news, "autoVerifiedAttributes")
+		diff, err := prov.Diff(p.DiffRequest{Urn: urn("CognitoEmailSender"), ID: created.ID, Olds: created.Properties, News: news})
+		So(err, ShouldBeNil)
+		So(diff.DetailedDiff["autoVerifiedAttributes"].Kind, ShouldEqual, p.Update)
+
+		updated, err := prov.Update(p.UpdateRequest{Urn: urn("CognitoEmailSender"), ID: created.ID, Olds: created.Properties, News: news})
+
+		So(err, ShouldBeNil)
+		So(autoVerifiedAttributes, ShouldResemble, []string{"email"})
+		So(updated.Properties["previousAutoVerifiedAttributes"].IsNull(), ShouldBeTrue)
+	})
+}
+
+func TestCognitoEmailSenderKeyPolicy(t *testing.T) {
+	prov := provider()
+
+	keyPolicy := ""
+	defer stubAWS(prov, func(target string) (int, string) {
+		switch {
+		case target == "TrentService.DescribeKey":
+			return http.StatusOK, `{"KeyMetadata":{"KeyId":"1234abcd-12ab-34cd-56ef-1234567890ab"}}`
+		case target == "TrentService.GetKeyPolicy":
+			policy, _ := json.Marshal(keyPolicy)
+			return http.StatusOK, fmt.Sprintf(`{"PolicyName":"default","Policy":%s}`, policy)
+		case target == "AWSCognitoIdentityProviderService.DescribeUserPool":
+			return http.StatusOK, `{"UserPool":{"Id":"eu-west-1_AbCdEfGhI","Arn":"arn:aws:cognito-idp:eu-west-1:123456789012:userpool/eu-west-1_AbCdEfGhI"}}`
+		case target == "AWSCognitoIdentityProviderService.UpdateUserPool":
+			return http.StatusOK, `{}`
+		case strings.HasPrefix(target, "POST /2015-03-31/functions/"):
+			return http.StatusCreated, `{"Statement":"{}"}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	inputs := resource.PropertyMap{
+		"region":            resource.NewStringProperty("eu-west-1"),
+		"userPoolId":        resource.NewStringProperty("eu-west-1_AbCdEfGhI"),
+		"lambdaArn":         resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:email-sender"),
+		"kmsKeyArn":         resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+		"managePermissions": resource.NewBoolProperty(true),
+	}
+
+	Convey("When the key policy allows cognito next to statements for everyone", t, func() {
+		keyPolicy = `{"Version":"2012-10-17","Statement":[
+			{"Effect":"Allow","Principal":"*","Action":"kms:DescribeKey","Resource":"*","Condition":{"StringEquals":{"kms:CallerAccount":"123456789012"}}},
+			{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::123456789012:root"]},"Action":"kms:*","Resource":"*"},
+			{"Effect":"Allow","Principal":{"Service":"cognito-idp.amazonaws.com"},"Action":["kms:Encrypt","kms:CreateGrant"],"Resource":"*"}
+		]}`
+		_, err := prov.Create(p.CreateRequest{Urn: urn("CognitoEmailSender"), Properties: inputs})
+
+		So(err, ShouldBeNil)
+	})
+
+	Convey("When the key policy allows everyone", t, func() {
+		keyPolicy = `{"Version":"2012-10-17","Statement":[
+			{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"kms:*","Resource":"*"}
+		]}`
+		_, err := prov.Create(p.CreateRequest{Urn: urn("CognitoEmailSender"), Properties: inputs})
+
+		So(err, ShouldBeNil)
+	})
+
+	Convey("When the key policy only allows the account", t, func() {
+		keyPolicy = `{"Version":"2012-10-17","Statement":[
+			{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"kms:*","Resource":"*"},
+			{"Effect":"Allow","Principal":{"Federated":["cognito-identity.amazonaws.com"]},"Action":"kms:Decrypt","Resource":"*"}
+		]}`
+		_, err := prov.Create(p.CreateRequest{Urn: urn("CognitoEmailSender"), Properties: inputs})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "must allow cognito-idp.amazonaws.com to use kms:Encrypt and kms:CreateGrant")
+	})
+}
+
+func TestCognitoWorkmailEmailConfiguration(t *testing.T) {
+	prov := provider()
+
+	verified := true
+	var emailConfiguration map[string]any
+	defer stubAWSRequests(prov, func(target string, body []byte) (int, string) {
+		switch target {
+		case "WorkMailService.DescribeUser":
+			return http.StatusOK, `{"UserId":"USER_ID","Name":"support","Email":"support@dev.gothub.io","State":"ENABLED"}`
+		case "GET /v2/email/identities/dev.gothub.io", "GET /v2/email/identities/gothub.io":
+			return http.StatusOK, fmt.Sprintf(`{"IdentityType":"DOMAIN","VerifiedForSendingStatus":%t}`, verified)
+		case "AWSCognitoIdentityProviderService.DescribeUserPool":
+			return http.StatusOK, `{"UserPool":{"Id":"eu-west-1_AbCdEfGhI","EmailConfiguration":{"EmailSendingAccount":"DEVELOPER","SourceArn":"arn:aws:ses:eu-west-1:123456789012:identity/noreply@gothub.io","From":"noreply@gothub.io"}}}`
+		case "AWSCognitoIdentityProviderService.UpdateUserPool":
+			var update struct{ EmailConfiguration map[string]any }
+			if err := json.Unmarshal(body, &update); err != nil {
+				return http.StatusBadRequest, `{"__type":"InvalidParameterException","message":"Invalid request body"}`
+			}
+			emailConfiguration = update.EmailConfiguration
+			return http.StatusOK, `{}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	inputs := resource.PropertyMap{
+		"region":         resource.NewStringProperty("eu-west-1"),
+		"userPoolId":     resource.NewStringProperty("eu-west-1_AbCdEfGhI"),
+		"organizationId": resource.NewStringProperty("ORGANIZATION_ID"),
+		"userId":         resource.NewStringProperty("USER_ID"),
+		"sesIdentityArn": resource.NewStringProperty("arn:aws:ses:eu-west-1:123456789012:identity/dev.gothub.io"),
+		"fromName":       resource.NewStringProperty("Gothub Support"),
+	}
+
+	Convey("When sending the emails of a user pool from a WorkMail user", t, func() {
+		verified = true
+		created, err := prov.Create(p.CreateRequest{Urn: urn("CognitoWorkmailEmailConfiguration"), Properties: inputs})
+
+		So(err, ShouldBeNil)
+		So(created.Properties["fromAddress"].StringValue(), ShouldEqual, "support@dev.gothub.io")
+		So(emailConfiguration, ShouldResemble, map[string]any{
+			"EmailSendingAccount": "DEVELOPER",
+			"SourceArn":           "arn:aws:ses:eu-west-1:123456789012:identity/dev.gothub.io",
+			"From":                "Gothub Support <support@dev.gothub.io>",
+			"ReplyToEmailAddress": "support@dev.gothub.io",
+		})
+
+		Convey("When deleting the configuration", func() {
+			err := prov.Delete(p.DeleteRequest{Urn: urn("CognitoWorkmailEmailConfiguration"), ID: created.ID, Properties: created.Properties})
+
+			So(err, ShouldBeNil)
+			So(emailConfiguration, ShouldResemble, map[string]any{
+				"EmailSendingAccount": "DEVELOPER",
+				"SourceArn":           "arn:aws:ses:eu-west-1:123456789012:identity/noreply@gothub.io",
+				"From":                "noreply@gothub.io",
+			})
+		})
+	})
+
+	Convey("When the SES identity is not verified", t, func() {
+		verified = false
+		_, err := prov.Create(p.CreateRequest{Urn: urn("CognitoWorkmailEmailConfiguration"), Properties: inputs})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "is not verified for sending")
+	})
+
+	Convey("When the SES identity does not cover the address of the user", t, func() {
+		verified = true
+		news := inputs.Copy()
+		news["sesIdentityArn"] = resource.NewStringProperty("arn:aws:ses:eu-west-1:123456789012:identity/gothub.io")
+		_, err := prov.Create(p.CreateRequest{Urn: urn("CognitoWorkmailEmailConfiguration"), Properties: news})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "does not cover the address support@dev.gothub.io")
+	})
+}
+
+// httpClientFunc answers AWS requests without sending them.
+type httpClientFunc func(*http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestThrottledRequestsAreRetried(t *testing.T) {
+	prov := provider()
+
+	requests := 0
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests++
+		if requests <= 2 {
+			return http.StatusBadRequest, `{"__type":"ThrottlingException","message":"Rate exceeded"}`
+		}
+		return http.StatusOK, `{"OrganizationId":"m-123","Alias":"gothub","State":"Active","DefaultMailDomain":"dev.gothub.io"}`
+	})()
+
+	Convey("When WorkMail throttles a request", t, func() {
+		err := prov.Configure(p.ConfigureRequest{
+			Args: resource.PropertyMap{
+				"requestsPerSecond": resource.NewNumberProperty(100),
+				"burst":             resource.NewNumberProperty(1),
+			},
+		})
+		So(err, ShouldBeNil)
+
+		organization, err := prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:getOrganization",
+			Args: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("m-123"),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		So(requests, ShouldEqual, 3)
+		So(organization.Return["alias"].StringValue(), ShouldEqual, "gothub")
+	})
+}
+
+func TestInvalidRateLimitConfiguration(t *testing.T) {
+	configure := func(key string, value float64) error {
+		return provider().Configure(p.ConfigureRequest{
+			Args: resource.PropertyMap{resource.PropertyKey(key): resource.NewNumberProperty(value)},
+		})
+	}
+
+	Convey("When configuring no requests per second", t, func() {
+		err := configure("requestsPerSecond", 0)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "requestsPerSecond")
+	})
+
+	Convey("When configuring a negative burst", t, func() {
+		err := configure("burst", -1)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "burst")
+	})
+
+	Convey("When configuring no attempts", t, func() {
+		err := configure("maxAttempts", 0)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "maxAttempts")
+	})
+}
+
+func TestOrganizationFailureIncludesRequestId(t *testing.T) {
+	prov := provider()
+
+	defer stubAWS(prov, func(target string) (int, string) {
+		switch target {
+		case "WorkMailService.CreateOrganization":
+			return http.StatusOK, `{"OrganizationId":"m-123"}`
+		case "WorkMailService.DescribeOrganization":
+			return http.StatusOK, `{"OrganizationId":"m-123","State":"Failed","ErrorMessage":"Directory limit reached"}`
+		}
+		return http.StatusBadRequest, `{"__type":"OrganizationNotFoundException","message":"Organization not found"}`
+	})()
+
+	Convey("When the organization fails to become active", t, func() {
+		response, err := prov.Create(p.CreateRequest{
+			Urn: urn("Organization"),
+			Properties: resource.PropertyMap{
+				"region": resource.NewStringProperty("eu-west-1"),
+				"alias":  resource.NewStringProperty("gothub"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(response.ID, ShouldEqual, "m-123")
+		So(response.Properties["organizationId"].StringValue(), ShouldEqual, "m-123")
+		So(response.PartialState, ShouldNotBeNil)
+		So(response.PartialState.Reasons[0], ShouldContainSubstring, "Directory limit reached")
+		So(response.PartialState.Reasons[0], ShouldContainSubstring, "RequestID: REQUEST_ID")
+	})
+
+	Convey("When a WorkMail request fails", t, func() {
+		_, err := prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:getMailDomain",
+			Args: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("m-404"),
+				"domainName":     resource.NewStringProperty("dev.gothub.io"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "RequestID: REQUEST_ID")
+	})
+}
+
+func TestWorkmailErrorTranslation(t *testing.T) {
+	prov := provider()
+
+	requests := map[string]int{}
+	defer stubAWS(prov, func(target string) (int, string) {
+		requests[target]++
+		switch target {
+		case "WorkMailService.CreateUser":
+			return http.StatusBadRequest, `{"__type":"NameAvailabilityException","message":"Name is not available"}`
+		case "WorkMailService.GetMailDomain":
+			return http.StatusBadRequest, `{"__type":"MailDomainNotFoundException","message":"Domain not found"}`
+		case "WorkMailService.DescribeOrganization":
+			if requests[target] == 1 {
+				return http.StatusBadRequest, `{"__type":"OrganizationStateException","message":"Organization is not active"}`
+			}
+			return http.StatusOK, `{"OrganizationId":"m-123","Alias":"gothub","State":"Active","DefaultMailDomain":"dev.gothub.io"}`
+		case "WorkMailService.ListUsers":
+			return http.StatusBadRequest, `{"__type":"InvalidParameterException","message":"Invalid filter"}`
+		case "AWSCognitoIdentityProviderService.DescribeUserPool":
+			// A code that is retried for WorkMail.
+			return http.StatusBadRequest, `{"__type":"OrganizationStateException","message":"Not a WorkMail error"}`
+		}
+		return http.StatusBadRequest, `{"__type":"UnsupportedOperationException","message":"Unexpected request"}`
+	})()
+
+	Convey("When the name of a user is taken", t, func() {
+		_, err := prov.Create(p.CreateRequest{
+			Urn: urn("User"),
+			Properties: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("m-123"),
+				"displayName":    resource.NewStringProperty("Info"),
+				"name":           resource.NewStringProperty("info"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "name info is already taken in organization m-123: choose another name or import the existing entity")
+		So(err.Error(), ShouldContainSubstring, "RequestID: REQUEST_ID")
+	})
+
+	Convey("When a mail domain is unknown", t, func() {
+		_, err := prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:getMailDomain",
+			Args: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("m-123"),
+				"domainName":     resource.NewStringProperty("dev.gothub.io"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "mail domain dev.gothub.io not found in organization m-123: register the domain with a DefaultDomain resource first")
+	})
+
+	Convey("When the organization is not active yet", t, func() {
+		organization, err := prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:getOrganization",
+			Args: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("m-123"),
+			},
+		})
+
+		So(err, ShouldBeNil)
+		So(requests["WorkMailService.DescribeOrganization"], ShouldEqual, 2)
+		So(organization.Return["alias"].StringValue(), ShouldEqual, "gothub")
+	})
+
+	Convey("When WorkMail reports an error without a translation", t, func() {
+		_, err := prov.Invoke(p.InvokeRequest{
+			Token: "awsworkmail:index:listUsers",
+			Args: resource.PropertyMap{
+				"region":         resource.NewStringProperty("eu-west-1"),
+				"organizationId": resource.NewStringProperty("m-123"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "InvalidParameterException: Invalid filter")
+		So(requests["WorkMailService.ListUsers"], ShouldEqual, 1)
+	})
+
+	Convey("When another service reports an error code that WorkMail retries", t, func() {
+		_, err := prov.Create(p.CreateRequest{
+			Urn: urn("CognitoEmailSender"),
+			Properties: resource.PropertyMap{
+				"region":     resource.NewStringProperty("eu-west-1"),
+				"userPoolId": resource.NewStringProperty("eu-west-1_AbCdEfGhI"),
+				"lambdaArn":  resource.NewStringProperty("arn:aws:lambda:eu-west-1:123456789012:function:email-sender"),
+				"kmsKeyArn":  resource.NewStringProperty("arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
+			},
+		})
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "Not a WorkMail error")
+		So(requests["AWSCognitoIdentityProviderService.DescribeUserPool"], ShouldEqual, 1)
+	})
+}
+
+// stubAWS answers all AWS requests of the provider with the status and body returned by
+// respond for the X-Amz-Target of the request, or its method and path for REST APIs like
+// "GET /v2/email/identities/dev.gothub.io". It configures the provider, so its clients
+// are not shared with other tests. It returns a function that restores the AWS
+// configuration.
+func stubAWS(prov integration.Server, respond func(target string) (int, string)) func() {
+	return stubAWSRequests(prov, func(target string, body []byte) (int, string) {
+		return respond(target)
+	})
+}
+
+// stubAWSRequests is like stubAWS, but also passes the body of each request to respond.
+func stubAWSRequests(prov integration.Server, respond func(target string, body []byte) (int, string)) func() {
+	err := prov.Configure(p.ConfigureRequest{Args: resource.PropertyMap{}})
+	if err != nil {
+		panic(err)
+	}
+
+	loadAWSConfig := awsworkmail.LoadAWSConfig
+	awsworkmail.LoadAWSConfig = func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+		cfg, err := loadAWSConfig(ctx, optFns...)
+		cfg.Credentials = credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+		cfg.HTTPClient = httpClientFunc(func(request *http.Request) (*http.Response, error) {
+			var body []byte
+			if request.Body != nil {
+				body, err = io.ReadAll(request.Body)
+				if err != nil {
+					return nil, err
+				}
+			}
+			target := request.Header.Get("X-Amz-Target")
+			if target == "" {
+				target = request.Method + " " + request.URL.Path
+			}
+			status, response := respond(target, body)
+			return &http.Response{
+				StatusCode: status,
+				Header:     http.Header{"X-Amzn-Requestid": []string{"REQUEST_ID"}},
+				Body:       io.NopCloser(strings.NewReader(response)),
+				Request:    request,
+			}, nil
+		})
+		return cfg, err
+	}
+	return func() { awsworkmail.LoadAWSConfig = loadAWSConfig }
+}
+
 // urn is a helper function to build an urn for running integration tests.
 func urn(typ string) resource.URN {
 	return resource.NewURN("stack", "proj", "",